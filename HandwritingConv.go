@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/shimmy568/GoNeuralNetworks/core"
+	"github.com/shimmy568/GoNeuralNetworks/data"
+	"github.com/shimmy568/GoNeuralNetworks/metrics"
+)
+
+// runHandwritingConv trains and tests a LeNet style convolutional network on the handwriting images
+// Unlike runHandwritingFF the images keep their shape so the network can learn from the pixels around each pixel
+func runHandwritingConv() {
+	trainingImages, trainingLabels, testingImages, testingLabels := loadAndProcessData()
+
+	model, err := createLeNet(core.ImageShape{Channels: 1, Height: imageHeightHandwriting, Width: imageWidthHandwriting})
+	if err != nil {
+		log.Fatal(err)
+	}
+	model.LearningRate = 0.01
+
+	trainHandwritingConv(model, trainingImages, trainingLabels)
+
+	testHandwritingConv(model, testingImages, testingLabels)
+}
+
+// createLeNet creates a LeNet style model for images of the given shape with two convolutions that are each
+// followed by max pooling to halve the size of the image and then three dense layers
+func createLeNet(inputShape core.ImageShape) (*core.Sequential, error) {
+	conv1, err := core.CreateConv2D(core.Conv2DConfig{InputShape: inputShape, Filters: 6, KernelSize: 5, Padding: 2, Initializer: core.HeNormal{}})
+	if err != nil {
+		return nil, err
+	}
+	pool1, err := core.CreateMaxPool2D(conv1.GetOutputShape(), 2, 0)
+	if err != nil {
+		return nil, err
+	}
+	conv2, err := core.CreateConv2D(core.Conv2DConfig{InputShape: pool1.GetOutputShape(), Filters: 16, KernelSize: 5, Initializer: core.HeNormal{}})
+	if err != nil {
+		return nil, err
+	}
+	pool2, err := core.CreateMaxPool2D(conv2.GetOutputShape(), 2, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.CreateSequential(
+		conv1,
+		core.CreateActivationLayer(core.ReLU{}),
+		pool1,
+		conv2,
+		core.CreateActivationLayer(core.ReLU{}),
+		pool2,
+		core.CreateDenseWithInitializer(pool2.GetOutputShape().Size(), 120, core.HeNormal{}),
+		core.CreateActivationLayer(core.ReLU{}),
+		core.CreateDenseWithInitializer(120, 84, core.HeNormal{}),
+		core.CreateActivationLayer(core.ReLU{}),
+		core.CreateDense(84, 10),
+		core.CreateActivationLayer(core.Softmax{}),
+	), nil
+}
+
+// trainHandwritingConv trains the model with the given images, keeping their shape
+func trainHandwritingConv(model *core.Sequential, images []*data.MonochromeImageData, labels []int) {
+	expectedOutputs := generateExpectedOutputFromLables(labels)
+
+	trainingData := make([]*core.TrainingItem, len(images))
+	for i := range images {
+		trainingData[i] = core.CreateImageTrainingItem(images[i], expectedOutputs[i])
+	}
+
+	// Hold back some of the training images to check the model on while it trains, the images are already shuffled
+	validationCount := len(trainingData) / 10
+	validationData := trainingData[:validationCount]
+	trainingData = trainingData[validationCount:]
+
+	earlyStopping := core.CreateEarlyStopping("validation_accuracy", 5)
+
+	_, err := model.Fit(trainingData, core.FitConfig{
+		Epochs:         epochCountHandwriting,
+		BatchSize:      16,
+		Shuffle:        true,
+		ValidationData: validationData,
+		Metrics:        []core.Metric{core.Accuracy{}},
+		Verbose:        true,
+		Callbacks:      []core.Callback{earlyStopping},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Best Epoch: %d\n", earlyStopping.GetBestEpoch())
+}
+
+// testHandwritingConv tests the model with the given images
+func testHandwritingConv(model *core.Sequential, images []*data.MonochromeImageData, labels []int) {
+	scores := make([][]float64, len(images))
+	for i := range images {
+		scores[i] = model.Predict(images[i].GetVector().RawVector().Data).RawVector().Data
+	}
+
+	report, err := metrics.CreateReport(scores, labels, 3)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(report.Text())
+}
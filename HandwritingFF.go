@@ -10,6 +10,7 @@ import (
 
 	"github.com/shimmy568/GoNeuralNetworks/core"
 	"github.com/shimmy568/GoNeuralNetworks/data"
+	"github.com/shimmy568/GoNeuralNetworks/metrics"
 	"github.com/shimmy568/GoNeuralNetworks/util"
 	"gonum.org/v1/gonum/mat"
 )
@@ -25,7 +26,14 @@ func runHandwritingFF() {
 	trainingImages, trainingLables, testingImages, testingLabels := loadAndProcessData()
 
 	// Create the network
-	n := core.CreateNetwork(imageWidthHandwriting*imageHeightHandwriting, 10, 1, 200, 0.05)
+	n, err := core.CreateNetworkFromConfig(core.NetworkConfig{
+		LayerSizes:   []int{imageWidthHandwriting * imageHeightHandwriting, 200, 10},
+		Activations:  []core.Activation{core.Sigmoid{}, core.Softmax{}},
+		LearningRate: 0.05,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Train the network
 	trainHandwritingFF(&n, trainingImages, trainingLables)
@@ -87,70 +95,61 @@ func loadAndProcessData() ([]*data.MonochromeImageData, []int, []*data.Monochrom
 func trainHandwritingFF(n *core.NeuralNet, images []*data.MonochromeImageData, labels []int) {
 	expectedOutputs := generateExpectedOutputFromLables(labels)
 
-	// Create a list that will be used to randomize the order of images that we train on per epoch
-	order := make([]int, len(images))
-	for i := 0; i < len(order); i++ {
-		order[i] = i
+	// Turn the images into training items
+	trainingData := make([]*core.TrainingItem, len(images))
+	for i := range images {
+		trainingData[i] = core.CreateMonochromeImageTrainingItem(images[i], expectedOutputs[i])
 	}
 
-	// Train the network on the trainging data for the number of epochs
-	for o := 0; o < epochCountHandwriting; o++ {
-		fmt.Printf("Epoch: %d/%d\n", o+1, epochCountHandwriting) // Print the current epoch number
-		for i := 0; i < len(images); i++ {
-			// Shuffle order array
-			util.GetRand().Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
-
-			// Train the network on the image given
-			err := n.TrainMonnochromeImage(images[order[i]], expectedOutputs[order[i]])
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-		testHandwritingFF(n, images, labels)
+	// Hold back some of the training images to check the network on while it trains, the images are already shuffled
+	validationCount := len(trainingData) / 10
+	validationData := trainingData[:validationCount]
+	trainingData = trainingData[validationCount:]
+
+	// Stop once the validation accuracy stops going up and keep the weights from the best epoch
+	earlyStopping := core.CreateEarlyStopping("validation_accuracy", 5)
+
+	// Train the network on the training data for the number of epochs, shuffling the order of the images every epoch
+	_, err := n.Fit(trainingData, core.FitConfig{
+		Epochs:         epochCountHandwriting,
+		BatchSize:      1,
+		Shuffle:        true,
+		ValidationData: validationData,
+		Metrics:        []core.Metric{core.Accuracy{}},
+		Verbose:        true,
+		Callbacks:      []core.Callback{earlyStopping},
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
+
+	fmt.Printf("Best Epoch: %d\n", earlyStopping.GetBestEpoch())
 }
 
 // testHandwritingFF tests the network with the given data
 func testHandwritingFF(n *core.NeuralNet, images []*data.MonochromeImageData, labels []int) {
-	imageCount := len(images)
-
-	// Test network on dataset to check trained accuracy
-	gotRight := 0
-	for i := 0; i < imageCount; i++ {
+	// Run every image through the network and keep its outputs
+	scores := make([][]float64, len(images))
+	for i := range images {
 		result, err := n.PredictMonochromeImage(images[i])
 		if err != nil {
 			log.Fatal(err)
 		}
-
-		// Find the index with the highest output
-		maxIndex := -1
-		for o := 0; o < result.Len(); o++ {
-			if maxIndex == -1 {
-				maxIndex = o
-			} else {
-				if result.AtVec(maxIndex) < result.AtVec(o) {
-					maxIndex = o
-				}
-			}
-		}
-
-		// Check if the network predicted correctly
-		if maxIndex == labels[i] {
-			gotRight++
-		}
+		scores[i] = result.RawVector().Data
 	}
 
-	fmt.Printf("Got Right: %d, Out Of: %d, Ratio: %f\n", gotRight, imageCount, float64(gotRight)/float64(imageCount))
+	report, err := metrics.CreateReport(scores, labels, 3)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(report.Text())
 }
 
 // generateExpectedOutputFromLables creates the expected output vector from what number the label is
 func generateExpectedOutputFromLables(labels []int) (expectedOutputs []*mat.VecDense) {
 	for i := 0; i < len(labels); i++ {
-		tmp := make([]float64, 10) // Create array that will hold the data temperatorly
-		for i := range tmp {
-			tmp[i] = 0.001
-		}
-		tmp[labels[i]] = 0.999                                              // Set the corresponding numbers index to 0.999
+		tmp := make([]float64, 10)                                          // Create array that will hold the data temperatorly
+		tmp[labels[i]] = 1                                                  // Set the corresponding numbers index to 1
 		expectedOutputs = append(expectedOutputs, mat.NewVecDense(10, tmp)) // Create the vec dense from the tmp array and append it to output list
 	}
 
@@ -1,6 +1,15 @@
 package main
 
+import "github.com/shimmy568/GoNeuralNetworks/util"
+
+// randomSeed seeds all the randomness used by the examples, from the initial weights to the order items are trained in,
+// so running them again gives the same results
+const randomSeed = 1
+
 func main() {
+	util.SetSeed(randomSeed)
+
 	runMnistDataFF()
 	//runHandwritingFF()
+	//runHandwritingConv()
 }
@@ -5,7 +5,6 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"math/rand"
 	"os"
 	"strconv"
 	"time"
@@ -13,6 +12,7 @@ import (
 	"gonum.org/v1/gonum/mat"
 
 	"github.com/shimmy568/GoNeuralNetworks/core"
+	"github.com/shimmy568/GoNeuralNetworks/metrics"
 )
 
 const epochCountMnist = 1
@@ -20,13 +20,21 @@ const epochCountMnist = 1
 // This file is for holding the logic associated with having
 
 func runMnistDataFF() {
-	n := core.CreateNetwork(28*28, 10, 1, 200, 0.1)
+	// Use a softmax output layer so the network outputs the probability of each digit
+	n, err := core.CreateNetworkFromConfig(core.NetworkConfig{
+		LayerSizes:   []int{28 * 28, 200, 10},
+		Activations:  []core.Activation{core.Sigmoid{}, core.Softmax{}},
+		LearningRate: 0.1,
+	})
+	if err != nil {
+		panic(err)
+	}
 
 	mnistTrain(&n)
 	n.SaveWeights("test.model")
 
 	n2 := core.CreateNetwork(28*28, 10, 1, 200, 0.1)
-	err := n2.LoadWeights("test.model")
+	err = n2.LoadWeights("test.model")
 	if err != nil {
 		panic(err)
 	}
@@ -41,7 +49,6 @@ func printStrArray(arr []string) {
 }
 
 func mnistTrain(net *core.NeuralNet) {
-	rand.Seed(time.Now().UTC().UnixNano())
 	startTime := time.Now()
 
 	trainingData := make([]*core.TrainingItem, 0)
@@ -63,30 +70,29 @@ func mnistTrain(net *core.NeuralNet) {
 			inputs.SetVec(i, (x/255.0*0.999)+0.001)
 		}
 
-		// Create the vector that holds the expected output for the output layer of the network
+		// Create the one hot vector that holds the expected output for the output layer of the network
 		targets := mat.NewVecDense(10, nil)
-		for i := 0; i < 10; i++ {
-			targets.SetVec(i, 0.001)
-		}
 		x, _ := strconv.Atoi(csvRow[0])
-		targets.SetVec(x, 0.999)
+		targets.SetVec(x, 1)
 
 		// Train the network on the data item
 		trainingData = append(trainingData, core.CreateTrainingItem(inputs, targets))
 	}
 	testFile.Close()
 
-	// Train the network on the data we loaded in
-	for epochs := 0; epochs < epochCountMnist; epochs++ {
-		fmt.Printf("Epoch #%d\n", epochs)
-		for i := range trainingData {
-			// Print what item we are training on every 1000 items
-			if i%1000 == 0 {
-				fmt.Printf("Item: %d\n", i)
-			}
-
-			net.Train(trainingData[i])
-		}
+	// Train the network on the data we loaded in one item at a time
+	_, err := net.Fit(trainingData, core.FitConfig{
+		Epochs:    epochCountMnist,
+		BatchSize: 1,
+		Shuffle:   true,
+		Metrics:   []core.Metric{core.Accuracy{}},
+		Verbose:   true,
+
+		// Print the loss every 1000 items
+		Callbacks: []core.Callback{core.ProgressLogger{Every: 1000}},
+	})
+	if err != nil {
+		panic(err)
 	}
 
 	elapsed := time.Since(startTime)
@@ -98,40 +104,33 @@ func mnistPredict(net *core.NeuralNet) {
 	checkFile, _ := os.Open("mnist_dataset/mnist_test.csv")
 	defer checkFile.Close()
 
-	score := 0
+	// Run every test item through the network and keep its outputs and label
+	var scores [][]float64
+	var labels []int
 	r := csv.NewReader(bufio.NewReader(checkFile))
-	count := 0
 	for {
-		count++
 		record, err := r.Read()
 		if err == io.EOF {
 			break
 		}
 		inputs := make([]float64, net.GetInputCount())
 		for i := range inputs {
-			if i == 0 {
-				inputs[i] = 1.0
-			}
 			x, _ := strconv.ParseFloat(record[i], 64)
 			inputs[i] = (x / 255.0 * 0.999) + 0.001
 		}
 		outputs := net.Predict(inputs)
-		best := 0
-		highest := 0.0
-
-		for i := 0; i < net.GetOutputCount(); i++ {
-			if outputs.AtVec(i) > highest {
-				best = i
-				highest = outputs.At(i, 0)
-			}
-		}
 		target, _ := strconv.Atoi(record[0])
-		if best == target {
-			score++
-		}
+
+		scores = append(scores, outputs.RawVector().Data)
+		labels = append(labels, target)
 	}
 
 	elapsed := time.Since(t1)
 	fmt.Printf("Time taken to check: %s\n", elapsed)
-	fmt.Printf("score: %d, out of: %d, ratio: %f\n", score, count, float64(score)/float64(count))
+
+	report, err := metrics.CreateReport(scores, labels, 3)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Print(report.Text())
 }
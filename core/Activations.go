@@ -0,0 +1,404 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the activation functions that can be used by the layers of a network
+
+// Activation is a function that is applied to the inputs of a layer to get the layer's outputs
+type Activation interface {
+	// Name returns the name that the activation is saved as in model files
+	Name() string
+
+	// Forward applies the activation to a matrix of layer inputs
+	Forward(z mat.Matrix) *mat.Dense
+
+	// Backward takes the layer inputs z, the layer outputs a and the gradient of the loss with respect to a
+	// and returns the gradient of the loss with respect to z
+	Backward(z, a, grad mat.Matrix) *mat.Dense
+}
+
+// parametricActivation is an activation that has a parameter that is learned while training
+type parametricActivation interface {
+	Activation
+
+	// paramGradient returns the gradient of the loss with respect to the parameter of the activation
+	paramGradient(z, grad mat.Matrix) float64
+
+	// getParam and setParam get and set the value of the parameter of the activation
+	getParam() float64
+	setParam(value float64)
+
+	// copyActivation returns a new activation with the same parameter so it can be changed separately
+	copyActivation() parametricActivation
+}
+
+// applyElementwise applies fn to every value in z
+func applyElementwise(fn func(z float64) float64, z mat.Matrix) *mat.Dense {
+	return apply(func(i, j int, v float64) float64 {
+		return fn(v)
+	}, z)
+}
+
+// backwardElementwise multiplies grad by the derivative of an element wise activation
+func backwardElementwise(derivative func(z, a float64) float64, z, a, grad mat.Matrix) *mat.Dense {
+	return apply(func(i, j int, v float64) float64 {
+		return v * derivative(z.At(i, j), a.At(i, j))
+	}, grad)
+}
+
+// sigmoid is an implemention of the sigmoid function that won't overflow for large inputs
+func sigmoid(value float64) float64 {
+	if value >= 0 {
+		return 1 / (1 + math.Exp(-value))
+	}
+
+	e := math.Exp(value)
+	return e / (e + 1)
+}
+
+// Sigmoid is the logistic activation, it squashes values to between 0 and 1
+type Sigmoid struct{}
+
+// Name returns the name of the activation
+func (Sigmoid) Name() string { return "sigmoid" }
+
+// Forward applies the sigmoid function to z
+func (Sigmoid) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(sigmoid, z)
+}
+
+// Backward multiplies grad by the derivative of the sigmoid function, a * (1 - a)
+func (Sigmoid) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		return a * (1 - a)
+	}, z, a, grad)
+}
+
+// Linear is the identity activation, it is used for the output layer of regression networks
+type Linear struct{}
+
+// Name returns the name of the activation
+func (Linear) Name() string { return "linear" }
+
+// Forward returns a copy of z
+func (Linear) Forward(z mat.Matrix) *mat.Dense {
+	return mat.DenseCopyOf(z)
+}
+
+// Backward returns a copy of grad since the derivative is 1
+func (Linear) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return mat.DenseCopyOf(grad)
+}
+
+// Tanh is the hyperbolic tangent activation, it squashes values to between -1 and 1
+type Tanh struct{}
+
+// Name returns the name of the activation
+func (Tanh) Name() string { return "tanh" }
+
+// Forward applies tanh to z
+func (Tanh) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(math.Tanh, z)
+}
+
+// Backward multiplies grad by the derivative of tanh, 1 - a^2
+func (Tanh) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		return 1 - a*a
+	}, z, a, grad)
+}
+
+// ReLU is the rectified linear unit activation, max(0, z)
+type ReLU struct{}
+
+// Name returns the name of the activation
+func (ReLU) Name() string { return "relu" }
+
+// Forward applies max(0, z) to z
+func (ReLU) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(func(z float64) float64 {
+		return math.Max(0, z)
+	}, z)
+}
+
+// Backward passes grad through where z is positive
+func (ReLU) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		if z > 0 {
+			return 1
+		}
+		return 0
+	}, z, a, grad)
+}
+
+// LeakyReLU is a ReLU that lets a small fixed slope through for negative inputs
+type LeakyReLU struct {
+	// Alpha is the slope used for negative inputs, usually something small like 0.01
+	Alpha float64
+}
+
+// Name returns the name of the activation including its slope
+func (l LeakyReLU) Name() string { return "leakyrelu:" + formatActivationParam(l.Alpha) }
+
+// Forward applies the leaky ReLU to z
+func (l LeakyReLU) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(func(z float64) float64 {
+		return leakyReLU(z, l.Alpha)
+	}, z)
+}
+
+// Backward multiplies grad by 1 for positive inputs and alpha for negative ones
+func (l LeakyReLU) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		return leakyReLUPrime(z, l.Alpha)
+	}, z, a, grad)
+}
+
+// PReLU is a leaky ReLU where the slope for negative inputs is learned while training
+// Each layer should be given its own PReLU since the slope is stored in the activation
+type PReLU struct {
+	// Alpha is the current slope used for negative inputs, 0.25 is a common starting point
+	Alpha float64
+}
+
+// Name returns the name of the activation including its current slope
+func (p *PReLU) Name() string { return "prelu:" + formatActivationParam(p.Alpha) }
+
+// Forward applies the parametric ReLU to z
+func (p *PReLU) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(func(z float64) float64 {
+		return leakyReLU(z, p.Alpha)
+	}, z)
+}
+
+// Backward multiplies grad by 1 for positive inputs and alpha for negative ones
+func (p *PReLU) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		return leakyReLUPrime(z, p.Alpha)
+	}, z, a, grad)
+}
+
+// paramGradient returns the gradient of the loss with respect to alpha, the sum of grad * z for negative inputs
+func (p *PReLU) paramGradient(z, grad mat.Matrix) float64 {
+	r, c := z.Dims()
+	sum := 0.0
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if z.At(i, j) <= 0 {
+				sum += grad.At(i, j) * z.At(i, j)
+			}
+		}
+	}
+
+	return sum
+}
+
+// getParam returns alpha
+func (p *PReLU) getParam() float64 {
+	return p.Alpha
+}
+
+// setParam sets alpha
+func (p *PReLU) setParam(value float64) {
+	p.Alpha = value
+}
+
+// copyActivation returns a new PReLU with the same alpha
+func (p *PReLU) copyActivation() parametricActivation {
+	return &PReLU{Alpha: p.Alpha}
+}
+
+// leakyReLU returns z for positive inputs and alpha * z for negative ones
+func leakyReLU(z, alpha float64) float64 {
+	if z > 0 {
+		return z
+	}
+	return alpha * z
+}
+
+// leakyReLUPrime is the derivative of leakyReLU
+func leakyReLUPrime(z, alpha float64) float64 {
+	if z > 0 {
+		return 1
+	}
+	return alpha
+}
+
+// ELU is the exponential linear unit activation, it smoothly approaches -alpha for negative inputs
+type ELU struct {
+	// Alpha is the value the activation approaches for large negative inputs, usually 1
+	Alpha float64
+}
+
+// Name returns the name of the activation including alpha
+func (e ELU) Name() string { return "elu:" + formatActivationParam(e.Alpha) }
+
+// Forward applies the ELU to z
+func (e ELU) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(func(z float64) float64 {
+		if z > 0 {
+			return z
+		}
+		return e.Alpha * math.Expm1(z)
+	}, z)
+}
+
+// Backward multiplies grad by 1 for positive inputs and a + alpha for negative ones
+func (e ELU) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		if z > 0 {
+			return 1
+		}
+		return a + e.Alpha
+	}, z, a, grad)
+}
+
+// GELU is the gaussian error linear unit activation, z * Φ(z) where Φ is the standard normal cdf
+type GELU struct{}
+
+// Name returns the name of the activation
+func (GELU) Name() string { return "gelu" }
+
+// Forward applies the GELU to z
+func (GELU) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(func(z float64) float64 {
+		return z * normalCDF(z)
+	}, z)
+}
+
+// Backward multiplies grad by the derivative of the GELU, Φ(z) + z * φ(z)
+func (GELU) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		return normalCDF(z) + z*math.Exp(-z*z/2)/math.Sqrt(2*math.Pi)
+	}, z, a, grad)
+}
+
+// normalCDF is the cumulative distribution function of the standard normal distribution
+func normalCDF(z float64) float64 {
+	return 0.5 * (1 + math.Erf(z/math.Sqrt2))
+}
+
+// Softplus is a smooth version of ReLU, log(1 + e^z)
+type Softplus struct{}
+
+// Name returns the name of the activation
+func (Softplus) Name() string { return "softplus" }
+
+// Forward applies the softplus function to z
+func (Softplus) Forward(z mat.Matrix) *mat.Dense {
+	return applyElementwise(func(z float64) float64 {
+		// Written this way so that e^z doesn't overflow for large z
+		return math.Max(z, 0) + math.Log1p(math.Exp(-math.Abs(z)))
+	}, z)
+}
+
+// Backward multiplies grad by the derivative of softplus which is the sigmoid function
+func (Softplus) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	return backwardElementwise(func(z, a float64) float64 {
+		return sigmoid(z)
+	}, z, a, grad)
+}
+
+// Softmax turns each column of the layer into a probability distribution that sums to 1
+// It is meant for the output layer of classifiers, where Train pairs it with a cross entropy loss
+type Softmax struct{}
+
+// Name returns the name of the activation
+func (Softmax) Name() string { return "softmax" }
+
+// Forward applies the softmax function to each column of z
+func (Softmax) Forward(z mat.Matrix) *mat.Dense {
+	r, c := z.Dims()
+	o := mat.NewDense(r, c, nil)
+
+	for col := 0; col < c; col++ {
+		// Subtract the largest value in the column before taking the exponent so that it can't overflow
+		max := math.Inf(-1)
+		for row := 0; row < r; row++ {
+			max = math.Max(max, z.At(row, col))
+		}
+
+		sum := 0.0
+		for row := 0; row < r; row++ {
+			e := math.Exp(z.At(row, col) - max)
+			o.Set(row, col, e)
+			sum += e
+		}
+
+		for row := 0; row < r; row++ {
+			o.Set(row, col, o.At(row, col)/sum)
+		}
+	}
+
+	return o
+}
+
+// Backward multiplies grad by the jacobian of the softmax, for each column that is a * (grad - sum(grad * a))
+func (Softmax) Backward(z, a, grad mat.Matrix) *mat.Dense {
+	r, c := a.Dims()
+	o := mat.NewDense(r, c, nil)
+
+	for col := 0; col < c; col++ {
+		dot := 0.0
+		for row := 0; row < r; row++ {
+			dot += grad.At(row, col) * a.At(row, col)
+		}
+
+		for row := 0; row < r; row++ {
+			o.Set(row, col, a.At(row, col)*(grad.At(row, col)-dot))
+		}
+	}
+
+	return o
+}
+
+// formatActivationParam formats the parameter of an activation so it can be stored in its name
+func formatActivationParam(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// activationFromName creates an activation from the name it was saved as
+func activationFromName(name string) (Activation, error) {
+	// Split off the parameter of the activation if it has one
+	parts := strings.SplitN(name, ":", 2)
+	param := 0.0
+	if len(parts) == 2 {
+		var err error
+		param, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch parts[0] {
+	case "sigmoid":
+		return Sigmoid{}, nil
+	case "linear":
+		return Linear{}, nil
+	case "tanh":
+		return Tanh{}, nil
+	case "relu":
+		return ReLU{}, nil
+	case "leakyrelu":
+		return LeakyReLU{Alpha: param}, nil
+	case "prelu":
+		return &PReLU{Alpha: param}, nil
+	case "elu":
+		return ELU{Alpha: param}, nil
+	case "gelu":
+		return GELU{}, nil
+	case "softplus":
+		return Softplus{}, nil
+	case "softmax":
+		return Softmax{}, nil
+	}
+
+	return nil, errors.New("Unknown activation function: " + name)
+}
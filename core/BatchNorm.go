@@ -0,0 +1,176 @@
+package core
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the batch normalization layer
+
+// BatchNorm is a layer that normalizes each of its inputs to have a mean of 0 and a variance of 1 over a batch
+// and then scales and shifts them by the learned gamma and beta
+// While training it normalizes with the statistics of the batch and keeps running averages of them,
+// which are used instead during inference so the output of a sample doesn't depend on the rest of its batch
+type BatchNorm struct {
+	gamma *Param
+	beta  *Param
+
+	// runningMean and runningVariance are the averages of the statistics of every batch the layer has been trained on
+	runningMean     *mat.Dense
+	runningVariance *mat.Dense
+
+	// Momentum is how much of the running statistics is kept each training step, usually 0.9
+	Momentum float64
+
+	// Epsilon is added to the variance so the layer never divides by zero
+	Epsilon float64
+
+	// xHat and invStd are the normalized input and 1 / sqrt(variance + epsilon) from the last training call to Forward
+	// batchStats is false when that call used the running statistics because its batch only had one sample
+	xHat       *mat.Dense
+	invStd     []float64
+	batchStats bool
+}
+
+// CreateBatchNorm creates a batch normalization layer for the given number of inputs
+// gamma starts at 1 and beta at 0 so the layer only normalizes until it has been trained
+func CreateBatchNorm(inputCount int) *BatchNorm {
+	gamma := mat.NewDense(inputCount, 1, nil)
+	runningVariance := mat.NewDense(inputCount, 1, nil)
+	for i := 0; i < inputCount; i++ {
+		gamma.Set(i, 0, 1)
+		runningVariance.Set(i, 0, 1)
+	}
+
+	return &BatchNorm{
+		gamma:           createParam(gamma, false),
+		beta:            createParam(mat.NewDense(inputCount, 1, nil), false),
+		runningMean:     mat.NewDense(inputCount, 1, nil),
+		runningVariance: runningVariance,
+		Momentum:        0.9,
+		Epsilon:         1e-5,
+	}
+}
+
+// GetGamma returns the learned scale of each input as a column vector
+func (b *BatchNorm) GetGamma() *mat.Dense {
+	return b.gamma.Value
+}
+
+// GetBeta returns the learned shift of each input as a column vector
+func (b *BatchNorm) GetBeta() *mat.Dense {
+	return b.beta.Value
+}
+
+// GetRunningMean returns the running mean of each input that is used during inference
+func (b *BatchNorm) GetRunningMean() *mat.Dense {
+	return b.runningMean
+}
+
+// GetRunningVariance returns the running variance of each input that is used during inference
+func (b *BatchNorm) GetRunningVariance() *mat.Dense {
+	return b.runningVariance
+}
+
+// Forward normalizes every input, using the statistics of the batch while training and the running statistics otherwise
+// A training batch with a single sample has no variance so it is normalized with the running statistics,
+// which means batch normalization only learns its statistics from TrainBatch
+func (b *BatchNorm) Forward(input *mat.Dense, training bool) *mat.Dense {
+	r, c := input.Dims()
+	useBatch := training && c > 1
+
+	mean := make([]float64, r)
+	invStd := make([]float64, r)
+	for i := 0; i < r; i++ {
+		if !useBatch {
+			mean[i] = b.runningMean.At(i, 0)
+			invStd[i] = 1 / math.Sqrt(b.runningVariance.At(i, 0)+b.Epsilon)
+			continue
+		}
+
+		// Find the mean and variance of the input over the batch
+		sum := 0.0
+		for j := 0; j < c; j++ {
+			sum += input.At(i, j)
+		}
+		mean[i] = sum / float64(c)
+
+		variance := 0.0
+		for j := 0; j < c; j++ {
+			d := input.At(i, j) - mean[i]
+			variance += d * d
+		}
+		variance /= float64(c)
+		invStd[i] = 1 / math.Sqrt(variance+b.Epsilon)
+
+		// Update the running statistics, the running variance uses the unbiased estimate
+		b.runningMean.Set(i, 0, b.Momentum*b.runningMean.At(i, 0)+(1-b.Momentum)*mean[i])
+		unbiased := variance * float64(c) / float64(c-1)
+		b.runningVariance.Set(i, 0, b.Momentum*b.runningVariance.At(i, 0)+(1-b.Momentum)*unbiased)
+	}
+
+	// Normalize, scale and shift
+	xHat := apply(func(i, j int, v float64) float64 {
+		return (v - mean[i]) * invStd[i]
+	}, input)
+	output := apply(func(i, j int, v float64) float64 {
+		return b.gamma.Value.At(i, 0)*v + b.beta.Value.At(i, 0)
+	}, xHat)
+
+	if training {
+		b.xHat = xHat
+		b.invStd = invStd
+		b.batchStats = useBatch
+	}
+
+	return output
+}
+
+// Backward finds the gradients of gamma and beta and returns the gradient with respect to the input
+// When the batch statistics were used the gradient also goes through the mean and variance of the batch
+func (b *BatchNorm) Backward(grad *mat.Dense) *mat.Dense {
+	r, c := grad.Dims()
+	b.gamma.Grad = sumColumns(multiply(grad, b.xHat))
+	b.beta.Grad = sumColumns(grad)
+
+	if !b.batchStats {
+		return apply(func(i, j int, v float64) float64 {
+			return v * b.gamma.Value.At(i, 0) * b.invStd[i]
+		}, grad)
+	}
+
+	// dx = gamma * invStd / n * (n * dy - sum(dy) - xHat * sum(dy * xHat))
+	n := float64(c)
+	output := mat.NewDense(r, c, nil)
+	for i := 0; i < r; i++ {
+		scale := b.gamma.Value.At(i, 0) * b.invStd[i] / n
+		for j := 0; j < c; j++ {
+			output.Set(i, j, scale*(n*grad.At(i, j)-b.beta.Grad.At(i, 0)-b.xHat.At(i, j)*b.gamma.Grad.At(i, 0)))
+		}
+	}
+
+	return output
+}
+
+// Params returns gamma and beta
+func (b *BatchNorm) Params() []*Param {
+	return []*Param{b.gamma, b.beta}
+}
+
+// createWorker returns a copy of the layer that shares gamma and beta but has its own copy of the running statistics
+func (b *BatchNorm) createWorker() Layer {
+	return &BatchNorm{
+		gamma:           b.gamma.shareValue(),
+		beta:            b.beta.shareValue(),
+		runningMean:     mat.DenseCopyOf(b.runningMean),
+		runningVariance: mat.DenseCopyOf(b.runningVariance),
+		Momentum:        b.Momentum,
+		Epsilon:         b.Epsilon,
+	}
+}
+
+// state returns the running mean and variance so they are saved with the model
+func (b *BatchNorm) state() []*mat.Dense {
+	return []*mat.Dense{b.runningMean, b.runningVariance}
+}
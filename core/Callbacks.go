@@ -0,0 +1,83 @@
+package core
+
+import "fmt"
+
+// This file holds the callbacks that Fit calls while it trains so training can be watched and changed without a new loop
+
+// TrainingInfo is what Fit passes to its callbacks about the current state of training
+type TrainingInfo struct {
+	// Network is the network being trained, callbacks can change its learning rate or save its weights
+	Network *Sequential
+
+	// Epoch is the current epoch starting at 1 and Epochs is the number of epochs Fit was asked for
+	Epoch  int
+	Epochs int
+
+	// Batch is the index of the current batch in the epoch starting at 0 and Batches is the number of batches in each epoch
+	Batch   int
+	Batches int
+
+	// BatchSize is the number of items in the current batch and BatchLoss is its loss, which is only set at the end of the batch
+	BatchSize int
+	BatchLoss float64
+
+	// Record is the loss and metrics of the current epoch, it is only set at the end of the epoch
+	Record *EpochHistory
+
+	// History is the history of every epoch that has finished so far
+	History *History
+
+	// Stop can be set by a callback to stop training, Fit stops after the current batch
+	// and the epoch it stopped in is still measured and added to the history
+	Stop bool
+
+	// Err can be set by a callback that can't do its job, such as early stopping being asked to watch a value that doesn't exist
+	// It also stops training and Fit returns it along with the history
+	Err error
+}
+
+// Callback is called by Fit at the beginning and end of training, of each epoch and of each batch
+type Callback interface {
+	OnTrainBegin(info *TrainingInfo)
+	OnTrainEnd(info *TrainingInfo)
+	OnEpochBegin(info *TrainingInfo)
+	OnEpochEnd(info *TrainingInfo)
+	OnBatchBegin(info *TrainingInfo)
+	OnBatchEnd(info *TrainingInfo)
+}
+
+// BaseCallback does nothing for every event, it can be embedded in callbacks that only need some of the events
+type BaseCallback struct{}
+
+// OnTrainBegin is called before the first epoch
+func (BaseCallback) OnTrainBegin(info *TrainingInfo) {}
+
+// OnTrainEnd is called after training stops
+func (BaseCallback) OnTrainEnd(info *TrainingInfo) {}
+
+// OnEpochBegin is called before each epoch
+func (BaseCallback) OnEpochBegin(info *TrainingInfo) {}
+
+// OnEpochEnd is called after each epoch once its loss and metrics have been measured
+func (BaseCallback) OnEpochEnd(info *TrainingInfo) {}
+
+// OnBatchBegin is called before each batch is trained on
+func (BaseCallback) OnBatchBegin(info *TrainingInfo) {}
+
+// OnBatchEnd is called after each batch is trained on
+func (BaseCallback) OnBatchEnd(info *TrainingInfo) {}
+
+// ProgressLogger is a callback that prints the loss of the current batch every few batches
+type ProgressLogger struct {
+	BaseCallback
+
+	// Every is the number of batches between each print
+	Every int
+}
+
+// OnBatchEnd prints the batch number and its loss every few batches
+func (p ProgressLogger) OnBatchEnd(info *TrainingInfo) {
+	if p.Every > 0 && info.Batch%p.Every == 0 {
+		fmt.Printf("Epoch: %d, Batch: %d, Loss: %f\n", info.Epoch, info.Batch, info.BatchLoss)
+	}
+}
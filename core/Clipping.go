@@ -0,0 +1,94 @@
+package core
+
+import "math"
+
+// This file holds the gradient clippers that keep large gradients from blowing up the params while training
+
+// Clipper limits the size of the gradients found by backpropagation before the optimizer uses them
+type Clipper interface {
+	// Clip clips the gradients of the params in place, with the params of each layer in their own slice
+	// It returns true if any gradient was changed
+	Clip(layers [][]*Param) bool
+}
+
+// ClipByValue clips every value of every gradient to the range [-Value, Value]
+type ClipByValue struct {
+	// Value is the largest size a gradient value can have
+	Value float64
+}
+
+// Clip clips each gradient value on its own
+func (c ClipByValue) Clip(layers [][]*Param) bool {
+	clipped := false
+	for i := range layers {
+		for _, param := range layers[i] {
+			param.Grad.Apply(func(_, _ int, v float64) float64 {
+				if v > c.Value {
+					clipped = true
+					return c.Value
+				}
+				if v < -c.Value {
+					clipped = true
+					return -c.Value
+				}
+				return v
+			}, param.Grad)
+		}
+	}
+
+	return clipped
+}
+
+// ClipByLayerNorm scales the gradients of each layer down so the L2 norm of all the layer's gradients is at most MaxNorm
+type ClipByLayerNorm struct {
+	// MaxNorm is the largest norm the gradients of a layer can have
+	MaxNorm float64
+}
+
+// Clip scales down every layer whose gradients are too large
+func (c ClipByLayerNorm) Clip(layers [][]*Param) bool {
+	clipped := false
+	for i := range layers {
+		if scaleToNorm(layers[i], c.MaxNorm) {
+			clipped = true
+		}
+	}
+
+	return clipped
+}
+
+// ClipByGlobalNorm scales all the gradients of the network down together so the L2 norm of every gradient
+// in the network is at most MaxNorm, which keeps the direction of the update the same
+type ClipByGlobalNorm struct {
+	// MaxNorm is the largest norm the gradients of the network can have
+	MaxNorm float64
+}
+
+// Clip scales down all the gradients if they are too large
+func (c ClipByGlobalNorm) Clip(layers [][]*Param) bool {
+	var params []*Param
+	for i := range layers {
+		params = append(params, layers[i]...)
+	}
+
+	return scaleToNorm(params, c.MaxNorm)
+}
+
+// scaleToNorm scales the gradients of the params so the L2 norm of all of them together is at most maxNorm
+// It returns true if the gradients were scaled
+func scaleToNorm(params []*Param, maxNorm float64) bool {
+	sum := 0.0
+	for _, param := range params {
+		sum += sumSquares(param.Grad)
+	}
+
+	norm := math.Sqrt(sum)
+	if norm <= maxNorm {
+		return false
+	}
+
+	for _, param := range params {
+		param.Grad.Scale(maxNorm/norm, param.Grad)
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/util"
+)
+
+// This file holds the 2D convolutional layer
+
+// Conv2DConfig holds the options used to build a Conv2D layer with CreateConv2D
+type Conv2DConfig struct {
+	// InputShape is the shape of the images the layer takes
+	InputShape ImageShape
+
+	// Filters is the number of kernels the layer learns, which is the number of channels in its output
+	Filters int
+
+	// KernelSize is the width and height of each kernel
+	KernelSize int
+
+	// Stride is how far the kernel moves between outputs, it is 1 if left 0
+	Stride int
+
+	// Padding is the number of rows and columns of zeros added around every side of the input
+	// A padding of (KernelSize-1)/2 with a stride of 1 keeps the output the same size as the input
+	Padding int
+
+	// Initializer is used for the kernels, FanInUniform is used if left nil
+	// The fan in of each kernel is InputShape.Channels * KernelSize * KernelSize
+	Initializer Initializer
+
+	// Rand is the random number generator used to initialize the kernels, util.GetRand is used if it is nil
+	Rand *rand.Rand
+}
+
+// Conv2D is a layer that slides a set of learned kernels over its input images
+// Each output channel is one kernel applied to every channel of the input at each position, plus a bias
+type Conv2D struct {
+	inputShape  ImageShape
+	outputShape ImageShape
+	kernelSize  int
+	stride      int
+	padding     int
+
+	// weights has one row per filter and one column per value of a kernel,
+	// value (x, y) of the kernel for input channel c is at column c*KernelSize*KernelSize + y*KernelSize + x
+	weights *Param
+	biases  *Param
+
+	// patches holds the input patch under the kernel at every output position of each sample
+	// from the last training call to Forward, one matrix per sample
+	patches []*mat.Dense
+}
+
+// CreateConv2D creates a convolutional layer with random kernels and biases set to zero
+func CreateConv2D(config Conv2DConfig) (*Conv2D, error) {
+	if err := config.InputShape.check(); err != nil {
+		return nil, err
+	}
+	if config.Filters <= 0 {
+		return nil, errors.New("A convolutional layer needs at least one filter")
+	}
+	if config.KernelSize <= 0 {
+		return nil, errors.New("Kernel size must be at least 1")
+	}
+	if config.Stride < 0 || config.Padding < 0 {
+		return nil, errors.New("Stride and padding can't be negative")
+	}
+
+	stride := config.Stride
+	if stride == 0 {
+		stride = 1
+	}
+
+	// The kernel has to fit inside the padded input at least once
+	in := config.InputShape
+	if in.Height+2*config.Padding < config.KernelSize || in.Width+2*config.Padding < config.KernelSize {
+		return nil, fmt.Errorf("Kernel size %d is larger than the padded %s input", config.KernelSize, in)
+	}
+	outputShape := ImageShape{
+		Channels: config.Filters,
+		Height:   (in.Height+2*config.Padding-config.KernelSize)/stride + 1,
+		Width:    (in.Width+2*config.Padding-config.KernelSize)/stride + 1,
+	}
+
+	initializer := config.Initializer
+	if initializer == nil {
+		initializer = FanInUniform{}
+	}
+	r := config.Rand
+	if r == nil {
+		r = util.GetRand()
+	}
+
+	weights := mat.NewDense(config.Filters, in.Channels*config.KernelSize*config.KernelSize, nil)
+	initializer.Initialize(weights, r)
+
+	return &Conv2D{
+		inputShape:  in,
+		outputShape: outputShape,
+		kernelSize:  config.KernelSize,
+		stride:      stride,
+		padding:     config.Padding,
+		weights:     createParam(weights, true),
+		biases:      createParam(mat.NewDense(config.Filters, 1, nil), false),
+	}, nil
+}
+
+// GetInputShape returns the shape of the images the layer takes
+func (l *Conv2D) GetInputShape() ImageShape {
+	return l.inputShape
+}
+
+// GetOutputShape returns the shape of the images the layer returns, its size is the input count of the next layer
+func (l *Conv2D) GetOutputShape() ImageShape {
+	return l.outputShape
+}
+
+// GetKernelSize returns the width and height of the kernels
+func (l *Conv2D) GetKernelSize() int {
+	return l.kernelSize
+}
+
+// GetStride returns how far the kernels move between outputs
+func (l *Conv2D) GetStride() int {
+	return l.stride
+}
+
+// GetPadding returns the number of rows and columns of zeros added around each side of the input
+func (l *Conv2D) GetPadding() int {
+	return l.padding
+}
+
+// GetWeights returns the kernels of the layer, one row per filter
+func (l *Conv2D) GetWeights() *mat.Dense {
+	return l.weights.Value
+}
+
+// GetBiases returns the biases of the layer as a column vector with one row per filter
+func (l *Conv2D) GetBiases() *mat.Dense {
+	return l.biases.Value
+}
+
+// forEachPatchValue calls fn for every value of the kernel at every output position that lands inside the input
+// with the column of the value in the weights, the output position and the row of the input it lands on
+// Values that land in the padding are skipped since the padding is always zero
+func (l *Conv2D) forEachPatchValue(fn func(kernelIndex int, position int, inputRow int)) {
+	in, out, k := l.inputShape, l.outputShape, l.kernelSize
+	for c := 0; c < in.Channels; c++ {
+		for ky := 0; ky < k; ky++ {
+			for kx := 0; kx < k; kx++ {
+				kernelIndex := (c*k+ky)*k + kx
+				for oy := 0; oy < out.Height; oy++ {
+					y := oy*l.stride - l.padding + ky
+					if y < 0 || y >= in.Height {
+						continue
+					}
+					for ox := 0; ox < out.Width; ox++ {
+						x := ox*l.stride - l.padding + kx
+						if x < 0 || x >= in.Width {
+							continue
+						}
+						fn(kernelIndex, oy*out.Width+ox, in.index(c, y, x))
+					}
+				}
+			}
+		}
+	}
+}
+
+// Forward applies every kernel at every position of each image in the batch
+func (l *Conv2D) Forward(input *mat.Dense, training bool) *mat.Dense {
+	rows, n := input.Dims()
+	if rows != l.inputShape.Size() {
+		panic(fmt.Sprintf("Conv2D expects %d inputs for %s images, got %d", l.inputShape.Size(), l.inputShape, rows))
+	}
+
+	filters, kernelLength := l.weights.Value.Dims()
+	positions := l.outputShape.Height * l.outputShape.Width
+
+	if training {
+		l.patches = make([]*mat.Dense, n)
+	}
+
+	output := mat.NewDense(l.outputShape.Size(), n, nil)
+	for j := 0; j < n; j++ {
+		// Lay the patch under the kernel at each position out as a column so every kernel can be applied with one product
+		patches := mat.NewDense(kernelLength, positions, nil)
+		l.forEachPatchValue(func(kernelIndex int, position int, inputRow int) {
+			patches.Set(kernelIndex, position, input.At(inputRow, j))
+		})
+
+		product := dot(l.weights.Value, patches)
+		for f := 0; f < filters; f++ {
+			bias := l.biases.Value.At(f, 0)
+			for p := 0; p < positions; p++ {
+				output.Set(f*positions+p, j, product.At(f, p)+bias)
+			}
+		}
+
+		if training {
+			l.patches[j] = patches
+		}
+	}
+
+	return output
+}
+
+// Backward finds the gradient of the kernels and biases summed over every position and sample
+// and returns the gradient with respect to the input
+func (l *Conv2D) Backward(grad *mat.Dense) *mat.Dense {
+	_, n := grad.Dims()
+	filters, kernelLength := l.weights.Value.Dims()
+	positions := l.outputShape.Height * l.outputShape.Width
+
+	weightsGrad := mat.NewDense(filters, kernelLength, nil)
+	biasesGrad := mat.NewDense(filters, 1, nil)
+	output := mat.NewDense(l.inputShape.Size(), n, nil)
+	for j := 0; j < n; j++ {
+		// Split the gradient of the sample into one row per filter to match the product in Forward
+		sampleGrad := mat.NewDense(filters, positions, nil)
+		for f := 0; f < filters; f++ {
+			sum := 0.0
+			for p := 0; p < positions; p++ {
+				v := grad.At(f*positions+p, j)
+				sampleGrad.Set(f, p, v)
+				sum += v
+			}
+			biasesGrad.Set(f, 0, biasesGrad.At(f, 0)+sum)
+		}
+
+		weightsGrad.Add(weightsGrad, dot(sampleGrad, l.patches[j].T()))
+
+		// Each input value was used by every patch it landed in, so its gradient is the sum over them
+		patchesGrad := dot(l.weights.Value.T(), sampleGrad)
+		l.forEachPatchValue(func(kernelIndex int, position int, inputRow int) {
+			output.Set(inputRow, j, output.At(inputRow, j)+patchesGrad.At(kernelIndex, position))
+		})
+	}
+
+	l.weights.Grad = weightsGrad
+	l.biases.Grad = biasesGrad
+	return output
+}
+
+// Params returns the kernels and biases of the layer
+func (l *Conv2D) Params() []*Param {
+	return []*Param{l.weights, l.biases}
+}
+
+// createWorker returns a copy of the layer that shares its kernels and biases
+func (l *Conv2D) createWorker() Layer {
+	return &Conv2D{
+		inputShape:  l.inputShape,
+		outputShape: l.outputShape,
+		kernelSize:  l.kernelSize,
+		stride:      l.stride,
+		padding:     l.padding,
+		weights:     l.weights.shareValue(),
+		biases:      l.biases.shareValue(),
+	}
+}
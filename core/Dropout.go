@@ -0,0 +1,95 @@
+package core
+
+import (
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/util"
+)
+
+// This file holds the dropout layer
+
+// Dropout is a layer that randomly zeroes a fraction of its inputs while training to keep the network from overfitting
+// It uses inverted dropout, the inputs that are kept are scaled up by 1 / (1 - rate) while training
+// so the layer does nothing at all during inference
+type Dropout struct {
+	// rate is the fraction of inputs that are dropped
+	rate float64
+
+	// rand is the random number generator used to pick which inputs are dropped
+	rand *rand.Rand
+
+	// mask is the scale applied to each input in the last training call to Forward, 0 for dropped inputs
+	mask *mat.Dense
+}
+
+// CreateDropout creates a dropout layer that drops the given fraction of its inputs while training
+// Its random number generator is seeded from util.GetRand, use SetSeed to give it a seed of its own
+func CreateDropout(rate float64) *Dropout {
+	return CreateDropoutWithSeed(rate, util.GetRand().Int63())
+}
+
+// CreateDropoutWithSeed creates a dropout layer that picks the inputs to drop with a random number generator using the given seed
+func CreateDropoutWithSeed(rate float64, seed int64) *Dropout {
+	return &Dropout{
+		rate: rate,
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// SetSeed reseeds the random number generator used to pick which inputs are dropped
+func (d *Dropout) SetSeed(seed int64) {
+	d.rand = rand.New(rand.NewSource(seed))
+}
+
+// GetRate returns the fraction of inputs that are dropped while training
+func (d *Dropout) GetRate() float64 {
+	return d.rate
+}
+
+// Forward drops inputs and scales up the rest while training and returns the input unchanged otherwise
+func (d *Dropout) Forward(input *mat.Dense, training bool) *mat.Dense {
+	if !training || d.rate <= 0 {
+		return input
+	}
+
+	// Pick which inputs are kept, the kept ones are scaled so the expected value of each input doesn't change
+	r, c := input.Dims()
+	d.mask = mat.NewDense(r, c, nil)
+	d.mask.Apply(func(i, j int, v float64) float64 {
+		if d.rand.Float64() < d.rate {
+			return 0
+		}
+		return 1 / (1 - d.rate)
+	}, d.mask)
+
+	return multiply(input, d.mask)
+}
+
+// Backward only lets the gradient through for the inputs that were kept
+func (d *Dropout) Backward(grad *mat.Dense) *mat.Dense {
+	if d.rate <= 0 {
+		return grad
+	}
+	return multiply(grad, d.mask)
+}
+
+// Params returns nil since dropout has nothing to learn
+func (d *Dropout) Params() []*Param {
+	return nil
+}
+
+// createWorker returns a copy of the layer with its own random number generator, seeded from this layer's
+func (d *Dropout) createWorker() Layer {
+	return &Dropout{
+		rate: d.rate,
+		rand: rand.New(rand.NewSource(d.rand.Int63())),
+	}
+}
+
+// snapshotCopy returns a copy of the layer without a random number generator, inference never drops anything
+// so taking a snapshot doesn't use up a value from the generator and change how the network trains
+func (d *Dropout) snapshotCopy() Layer {
+	return &Dropout{rate: d.rate}
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the early stopping callback that stops training once a metric stops improving
+
+// EarlyStopping is a callback that stops training when the monitored metric hasn't improved for Patience epochs
+// and can put the weights from the best epoch back into the network once training ends
+type EarlyStopping struct {
+	BaseCallback
+
+	// Monitor is the name of the value to watch, see EpochHistory.Value for the names that can be used
+	// Fit returns an error at the end of the first epoch if the value doesn't exist
+	Monitor string
+
+	// Patience is the number of epochs without improvement that are allowed before training is stopped
+	Patience int
+
+	// MinDelta is how much the value has to change by to count as an improvement
+	MinDelta float64
+
+	// Maximize is true for values where higher is better such as accuracy, otherwise lower is better
+	Maximize bool
+
+	// RestoreBestWeights puts the weights from the best epoch back into the network when training ends
+	RestoreBestWeights bool
+
+	best         float64
+	bestEpoch    int
+	bestWeights  []*mat.Dense
+	waited       int
+	stoppedEpoch int
+}
+
+// CreateEarlyStopping creates an early stopping callback that watches the given value and restores the best weights
+// Values with names ending in loss are minimized and every other value is maximized
+func CreateEarlyStopping(monitor string, patience int) *EarlyStopping {
+	return &EarlyStopping{
+		Monitor:            monitor,
+		Patience:           patience,
+		Maximize:           !strings.HasSuffix(monitor, "loss"),
+		RestoreBestWeights: true,
+	}
+}
+
+// GetBestEpoch returns the epoch with the best value of the monitored metric, or 0 if none has been seen
+func (e *EarlyStopping) GetBestEpoch() int {
+	return e.bestEpoch
+}
+
+// GetStoppedEpoch returns the epoch that training was stopped at, or 0 if it wasn't stopped early
+func (e *EarlyStopping) GetStoppedEpoch() int {
+	return e.stoppedEpoch
+}
+
+// OnTrainBegin resets the callback so it can be used for more than one call to Fit
+func (e *EarlyStopping) OnTrainBegin(info *TrainingInfo) {
+	e.bestEpoch = 0
+	e.bestWeights = nil
+	e.waited = 0
+	e.stoppedEpoch = 0
+}
+
+// OnEpochEnd checks if the monitored value improved and stops training if it hasn't for too long
+func (e *EarlyStopping) OnEpochEnd(info *TrainingInfo) {
+	value, ok := info.Record.Value(e.Monitor)
+	if !ok {
+		// The same values are measured every epoch so a missing value is a mistake in the name or the fit config
+		info.Err = fmt.Errorf("Early stopping can't monitor %q, the values that can be monitored are: %s",
+			e.Monitor, strings.Join(info.Record.Names(), ", "))
+		return
+	}
+
+	improved := value < e.best-e.MinDelta
+	if e.Maximize {
+		improved = value > e.best+e.MinDelta
+	}
+
+	if e.bestEpoch == 0 || improved {
+		e.best = value
+		e.bestEpoch = info.Epoch
+		e.waited = 0
+		if e.RestoreBestWeights {
+			e.bestWeights = info.Network.copyWeights()
+		}
+		return
+	}
+
+	e.waited++
+	if e.waited > e.Patience {
+		e.stoppedEpoch = info.Epoch
+		info.Stop = true
+	}
+}
+
+// OnTrainEnd puts the best weights back into the network
+func (e *EarlyStopping) OnTrainEnd(info *TrainingInfo) {
+	if e.RestoreBestWeights && e.bestWeights != nil {
+		info.Network.setWeights(e.bestWeights)
+	}
+}
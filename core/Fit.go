@@ -0,0 +1,296 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/util"
+)
+
+// This file holds Fit, the training loop that trains a network for a number of epochs and keeps a history of how it did
+
+// evaluationBatchSize is the number of items run through the network at once when evaluating a data set
+const evaluationBatchSize = 256
+
+// FitConfig holds the options for fitting a network to a set of training data
+type FitConfig struct {
+	// Epochs is the number of times to train on the whole training set
+	Epochs int
+
+	// BatchSize is the number of items in each mini-batch, 1 trains on one item at a time
+	BatchSize int
+
+	// Shuffle shuffles the order of the training items at the start of every epoch
+	Shuffle bool
+
+	// Rand is the random number generator used to shuffle the training items, util.GetRand is used if it is nil
+	Rand *rand.Rand
+
+	// Workers is the number of goroutines each batch is split across, 0 or 1 trains on a single goroutine
+	// Hogwild has the workers update the params without waiting for each other, see ParallelTrainer
+	// It only works with the SGD optimizer, Fit returns an error for any other optimizer
+	Workers int
+	Hogwild bool
+
+	// ValidationData is an optional set of items that is evaluated at the end of every epoch but never trained on
+	ValidationData []*TrainingItem
+
+	// Metrics are measured on the training data and the validation data at the end of every epoch
+	Metrics []Metric
+
+	// Verbose prints the loss and metrics at the end of every epoch
+	Verbose bool
+
+	// Callbacks are called at the beginning and end of training, of every epoch and of every batch
+	Callbacks []Callback
+}
+
+// EpochHistory is how the network did in a single epoch of fitting
+type EpochHistory struct {
+	// Epoch is the number of the epoch starting at 1
+	Epoch int
+
+	// Loss is the average training loss over the epoch
+	Loss float64
+
+	// Metrics holds the value of each metric on the training data at the end of the epoch, keyed by name
+	Metrics map[string]float64
+
+	// ValidationLoss and ValidationMetrics are the loss and metrics on the validation data at the end of the epoch
+	// They are only set if there is validation data
+	ValidationLoss    float64
+	ValidationMetrics map[string]float64
+
+	// LearningRate is the learning rate at the end of the epoch
+	LearningRate float64
+}
+
+// Value returns a value from the epoch by name, ok is false if the epoch doesn't have it
+// The names are "loss" and "validation_loss", and the name of a metric or the name prefixed with "validation_"
+func (e EpochHistory) Value(name string) (value float64, ok bool) {
+	switch {
+	case name == "loss":
+		return e.Loss, true
+	case name == "validation_loss":
+		return e.ValidationLoss, e.ValidationMetrics != nil
+	case strings.HasPrefix(name, "validation_"):
+		value, ok = e.ValidationMetrics[strings.TrimPrefix(name, "validation_")]
+		return value, ok
+	}
+
+	value, ok = e.Metrics[name]
+	return value, ok
+}
+
+// Names returns the name of every value the epoch has, in the order they are printed
+func (e EpochHistory) Names() []string {
+	names := []string{"loss"}
+	names = append(names, sortedKeys(e.Metrics)...)
+	if e.ValidationMetrics != nil {
+		names = append(names, "validation_loss")
+		for _, name := range sortedKeys(e.ValidationMetrics) {
+			names = append(names, "validation_"+name)
+		}
+	}
+	return names
+}
+
+// History is how the network did in every epoch of fitting
+type History struct {
+	Epochs []EpochHistory
+}
+
+// Fit trains the network on the training data for the number of epochs in the config and returns the history of every epoch
+// Training stops early if a callback sets Stop, and Fit returns an error along with the history if a callback sets Err
+// The scheduler is told about the end of every epoch with the validation loss, or the training loss if there is no validation data
+func (s *Sequential) Fit(trainingData []*TrainingItem, config FitConfig) (History, error) {
+	history := History{}
+
+	// Check the config and the data before any training is done
+	if config.BatchSize <= 0 {
+		return history, errors.New("Batch size must be at least 1")
+	}
+	err := checkTrainingItems(trainingData)
+	if err != nil {
+		return history, err
+	}
+	err = checkTrainingItems(config.ValidationData)
+	if err != nil {
+		return history, fmt.Errorf("Validation data: %v", err)
+	}
+
+	// Split each batch across the workers if there is more than one
+	step := s.trainMatrix
+	if config.Workers > 1 {
+		trainer, err := CreateParallelTrainer(s, config.Workers)
+		if err != nil {
+			return history, err
+		}
+		trainer.Hogwild = config.Hogwild
+		err = trainer.checkHogwild()
+		if err != nil {
+			return history, err
+		}
+		step = trainer.trainMatrix
+	}
+
+	r := config.Rand
+	if r == nil {
+		r = util.GetRand()
+	}
+
+	// Copy the training data so shuffling doesn't change the order of the caller's slice
+	items := make([]*TrainingItem, len(trainingData))
+	copy(items, trainingData)
+
+	info := &TrainingInfo{
+		Network: s,
+		Epochs:  config.Epochs,
+		Batches: (len(items) + config.BatchSize - 1) / config.BatchSize,
+		History: &history,
+	}
+	runCallbacks(config.Callbacks, Callback.OnTrainBegin, info)
+
+	for epoch := 1; epoch <= config.Epochs && !info.Stop && info.Err == nil; epoch++ {
+		info.Epoch = epoch
+		info.Record = nil
+		runCallbacks(config.Callbacks, Callback.OnEpochBegin, info)
+
+		if config.Shuffle {
+			r.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+		}
+
+		// Train on each batch and weight its loss by the number of items in it
+		totalLoss := 0.0
+		trained := 0
+		for info.Batch = 0; info.Batch < info.Batches && !info.Stop && info.Err == nil; info.Batch++ {
+			start := info.Batch * config.BatchSize
+			end := start + config.BatchSize
+			if end > len(items) {
+				end = len(items)
+			}
+			info.BatchSize = end - start
+			runCallbacks(config.Callbacks, Callback.OnBatchBegin, info)
+
+			input, targets := stackTrainingItems(items[start:end])
+			info.BatchLoss = step(input, targets)
+			totalLoss += info.BatchLoss * float64(end-start)
+			trained += end - start
+
+			runCallbacks(config.Callbacks, Callback.OnBatchEnd, info)
+		}
+		if trained > 0 {
+			s.lastLoss = totalLoss / float64(trained)
+		}
+
+		// Measure how the network did over the epoch
+		record := EpochHistory{
+			Epoch: epoch,
+			Loss:  s.lastLoss,
+		}
+		if len(config.Metrics) > 0 {
+			_, record.Metrics = s.evaluate(items, config.Metrics)
+		}
+		metric := record.Loss
+		if len(config.ValidationData) > 0 {
+			record.ValidationLoss, record.ValidationMetrics = s.evaluate(config.ValidationData, config.Metrics)
+			metric = record.ValidationLoss
+		}
+
+		s.EndEpoch(metric)
+		record.LearningRate = s.GetLearningRate()
+		history.Epochs = append(history.Epochs, record)
+
+		if config.Verbose {
+			fmt.Println(record.format(config.Epochs))
+		}
+
+		info.Record = &history.Epochs[len(history.Epochs)-1]
+		runCallbacks(config.Callbacks, Callback.OnEpochEnd, info)
+	}
+
+	runCallbacks(config.Callbacks, Callback.OnTrainEnd, info)
+
+	return history, info.Err
+}
+
+// runCallbacks calls the given event on every callback in order
+func runCallbacks(callbacks []Callback, event func(Callback, *TrainingInfo), info *TrainingInfo) {
+	for i := range callbacks {
+		event(callbacks[i], info)
+	}
+}
+
+// evaluate returns the loss and the value of every metric over a set of data without training on it
+// The data is run through the network in chunks so large data sets don't have to be stacked into one matrix
+func (s *Sequential) evaluate(data []*TrainingItem, metrics []Metric) (float64, map[string]float64) {
+	values := make(map[string]float64)
+	if len(data) == 0 {
+		return 0, values
+	}
+
+	// Run each chunk through the network and keep all the outputs for the metrics
+	loss := s.GetLoss()
+	totalLoss := 0.0
+	var outputs, targets *mat.Dense
+	for start := 0; start < len(data); start += evaluationBatchSize {
+		end := start + evaluationBatchSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		input, target := stackTrainingItems(data[start:end])
+		output := s.Forward(input, false)
+		totalLoss += loss.Value(output, target) * float64(end-start)
+
+		if len(metrics) > 0 {
+			if outputs == nil {
+				outputs = mat.NewDense(output.RawMatrix().Rows, len(data), nil)
+				targets = mat.NewDense(target.RawMatrix().Rows, len(data), nil)
+			}
+			outputs.Slice(0, output.RawMatrix().Rows, start, end).(*mat.Dense).Copy(output)
+			targets.Slice(0, target.RawMatrix().Rows, start, end).(*mat.Dense).Copy(target)
+		}
+	}
+
+	for i := range metrics {
+		values[metrics[i].Name()] = metrics[i].Value(outputs, targets)
+	}
+
+	return totalLoss/float64(len(data)) + s.penalty(), values
+}
+
+// format formats the history of an epoch as a single line for printing, epochs is the total number of epochs
+func (e EpochHistory) format(epochs int) string {
+	line := fmt.Sprintf("Epoch: %d/%d, Loss: %f", e.Epoch, epochs, e.Loss)
+	line += formatMetrics("", e.Metrics)
+	if e.ValidationMetrics != nil {
+		line += fmt.Sprintf(", Validation Loss: %f", e.ValidationLoss)
+		line += formatMetrics("Validation ", e.ValidationMetrics)
+	}
+	return line + fmt.Sprintf(", Learning Rate: %g", e.LearningRate)
+}
+
+// formatMetrics formats a set of metric values in a stable order for printing
+func formatMetrics(prefix string, metrics map[string]float64) string {
+	line := ""
+	for _, name := range sortedKeys(metrics) {
+		line += fmt.Sprintf(", %s%s: %f", prefix, name, metrics[name])
+	}
+	return line
+}
+
+// sortedKeys returns the names of a set of metric values in alphabetical order
+func sortedKeys(metrics map[string]float64) []string {
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,6 +1,9 @@
 package core
 
-import "gonum.org/v1/gonum/mat"
+import (
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
 
 // dot is a wrapper function for the gonum dot product functionality
 func dot(m, n mat.Matrix) *mat.Dense {
@@ -34,3 +37,35 @@ func subtract(m, n mat.Matrix) *mat.Dense {
 	o.Sub(m, n)
 	return o
 }
+
+// addColumnVector adds the column vector v to every column of m in place
+func addColumnVector(m *mat.Dense, v mat.Matrix) {
+	r, c := m.Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			m.Set(i, j, m.At(i, j)+v.At(i, 0))
+		}
+	}
+}
+
+// sumColumns adds all the columns of a matrix together into a single column vector
+func sumColumns(m mat.Matrix) *mat.Dense {
+	r, c := m.Dims()
+	o := mat.NewDense(r, 1, nil)
+	for i := 0; i < r; i++ {
+		sum := 0.0
+		for j := 0; j < c; j++ {
+			sum += m.At(i, j)
+		}
+		o.Set(i, 0, sum)
+	}
+	return o
+}
+
+// addScaled adds alpha * n to m in place without allocating, m and n need to be the same size
+func addScaled(m *mat.Dense, alpha float64, n *mat.Dense) {
+	a, b := m.RawMatrix(), n.RawMatrix()
+	for i := 0; i < a.Rows; i++ {
+		floats.AddScaled(a.Data[i*a.Stride:i*a.Stride+a.Cols], alpha, b.Data[i*b.Stride:i*b.Stride+b.Cols])
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the gradient checker that compares the gradients from backpropagation with finite differences
+
+// GradientCheckTolerance is the relative error below which a layer's gradients are usually taken to be correct
+const GradientCheckTolerance = 1e-5
+
+// LayerGradientCheck is the result of checking the gradients of a single layer
+type LayerGradientCheck struct {
+	// Layer is the index of the layer in the network
+	Layer int
+
+	// Type is the type of the layer such as *core.Dense
+	Type string
+
+	// RelativeError is ||analytic - numeric|| / (||analytic|| + ||numeric||) using the norms over all the params of the layer
+	RelativeError float64
+
+	// MaxAbsoluteError is the largest difference between a single analytic and numeric gradient
+	MaxAbsoluteError float64
+}
+
+// String formats the result for printing
+func (l LayerGradientCheck) String() string {
+	return fmt.Sprintf("Layer: %d (%s), Relative Error: %e, Max Absolute Error: %e", l.Layer, l.Type, l.RelativeError, l.MaxAbsoluteError)
+}
+
+// CheckGradients compares the gradient of every param found by backpropagation on the items with the
+// centred finite difference (loss(w + epsilon) - loss(w - epsilon)) / (2 * epsilon) and returns the error of each layer with params
+// The loss includes the regularizer penalty. Layers are run in training mode so batch normalization uses the batch statistics,
+// layers that are random while training such as dropout need to be left out of the network or have a rate of 0
+// The params and state of the network are the same after the check as before it
+func (s *Sequential) CheckGradients(items []*TrainingItem, epsilon float64) ([]LayerGradientCheck, error) {
+	if len(items) == 0 {
+		return nil, errors.New("Gradient check needs at least one item")
+	}
+	if epsilon <= 0 {
+		return nil, errors.New("Epsilon must be greater than 0")
+	}
+	err := checkTrainingItems(items)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the weights so the running statistics changed by the forward passes can be put back
+	saved := s.copyWeights()
+	defer s.setWeights(saved)
+
+	input, targets := stackTrainingItems(items)
+	loss := s.GetLoss()
+	lossAt := func() float64 {
+		return loss.Value(s.Forward(input, true), targets) + s.penalty()
+	}
+
+	// Find the analytic gradients with backpropagation, adding the gradient of the regularizer like applyGradients does
+	s.backward(loss, s.Forward(input, true), targets)
+	analytic := make(map[*Param]*mat.Dense)
+	for _, param := range s.Params() {
+		grad := mat.DenseCopyOf(param.Grad)
+		if s.Regularizer != nil && param.Regularize {
+			grad.Add(grad, s.Regularizer.Gradient(param.Value))
+		}
+		analytic[param] = grad
+	}
+
+	var results []LayerGradientCheck
+	for i := range s.layers {
+		params := s.layers[i].Params()
+		if len(params) == 0 {
+			continue
+		}
+
+		result := LayerGradientCheck{Layer: i, Type: fmt.Sprintf("%T", s.layers[i])}
+		difference, analyticSize, numericSize := 0.0, 0.0, 0.0
+		for _, param := range params {
+			r, c := param.Value.Dims()
+			for row := 0; row < r; row++ {
+				for col := 0; col < c; col++ {
+					// Find the loss on either side of the value
+					value := param.Value.At(row, col)
+					param.Value.Set(row, col, value+epsilon)
+					s.paramsUpdated()
+					plus := lossAt()
+					param.Value.Set(row, col, value-epsilon)
+					s.paramsUpdated()
+					minus := lossAt()
+					param.Value.Set(row, col, value)
+					s.paramsUpdated()
+
+					numeric := (plus - minus) / (2 * epsilon)
+					a := analytic[param].At(row, col)
+					difference += (a - numeric) * (a - numeric)
+					analyticSize += a * a
+					numericSize += numeric * numeric
+					result.MaxAbsoluteError = math.Max(result.MaxAbsoluteError, math.Abs(a-numeric))
+				}
+			}
+		}
+
+		// Use the norms of the whole layer so tiny gradients don't give a huge relative error
+		if analyticSize+numericSize > 0 {
+			result.RelativeError = math.Sqrt(difference) / (math.Sqrt(analyticSize) + math.Sqrt(numericSize))
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/util"
+)
+
+// gradientCheckNetworks returns the small networks to check, between them they use every activation and loss
+// The configs are made on every call since PReLU learns its slope and can't be shared between networks
+func gradientCheckNetworks() []NetworkConfig {
+	return []NetworkConfig{
+		{LayerSizes: []int{4, 5, 3}, Activations: []Activation{Sigmoid{}, Softmax{}}},
+		{LayerSizes: []int{4, 5, 5, 3}, Activations: []Activation{Tanh{}, GELU{}, Sigmoid{}}, Loss: BinaryCrossEntropy{}},
+		{LayerSizes: []int{4, 6, 3}, Activations: []Activation{ELU{Alpha: 1}, Linear{}}, Loss: Huber{Delta: 0.5}, Regularizer: L2{Lambda: 0.01}},
+		{LayerSizes: []int{4, 6, 3}, Activations: []Activation{Softplus{}, Sigmoid{}}, Loss: Focal{Gamma: 2, Alpha: 0.25}},
+		{LayerSizes: []int{4, 6, 3}, Activations: []Activation{&PReLU{Alpha: 0.2}, Softmax{}}, LayerNorm: true},
+		{LayerSizes: []int{4, 6, 3}, Activations: []Activation{LeakyReLU{Alpha: 0.1}, Softmax{}}, BatchNorm: true},
+	}
+}
+
+// gradientCheckConvolutions are the convolutional layers to check, each one is followed by a second
+// convolution so the gradient of its input is checked too and then a softmax output layer
+// Between them they use more than one channel, a stride and padding
+var gradientCheckConvolutions = []Conv2DConfig{
+	{InputShape: ImageShape{Channels: 1, Height: 5, Width: 6}, Filters: 2, KernelSize: 3},
+	{InputShape: ImageShape{Channels: 2, Height: 6, Width: 5}, Filters: 3, KernelSize: 3, Stride: 2, Padding: 1},
+	{InputShape: ImageShape{Channels: 3, Height: 4, Width: 4}, Filters: 2, KernelSize: 2, Stride: 2},
+}
+
+// gradientCheckPoolShape is the shape of the images given to the pooling layers that are checked
+var gradientCheckPoolShape = ImageShape{Channels: 2, Height: 6, Width: 6}
+
+// imageLayer is a layer that returns images, such as a convolution or a pooling layer
+type imageLayer interface {
+	Layer
+	GetOutputShape() ImageShape
+}
+
+// createGradientCheckPools creates the pooling layers to check
+// Their gradients are checked through the kernels of a convolution before them since they don't have params
+func createGradientCheckPools(shape ImageShape) ([]imageLayer, error) {
+	maxPool, err := CreateMaxPool2D(shape, 2, 0)
+	if err != nil {
+		return nil, err
+	}
+	overlappingMaxPool, err := CreateMaxPool2D(shape, 3, 1)
+	if err != nil {
+		return nil, err
+	}
+	avgPool, err := CreateAvgPool2D(shape, 2, 1)
+	if err != nil {
+		return nil, err
+	}
+	globalAvgPool, err := CreateGlobalAvgPool2D(shape)
+	if err != nil {
+		return nil, err
+	}
+
+	return []imageLayer{maxPool, overlappingMaxPool, avgPool, globalAvgPool}, nil
+}
+
+// randomGradientCheckItems creates items with random inputs and one hot targets
+func randomGradientCheckItems(inputCount int, outputCount int, count int) []*TrainingItem {
+	r := util.GetRand()
+	items := make([]*TrainingItem, count)
+	for i := range items {
+		inputs := mat.NewVecDense(inputCount, nil)
+		for o := 0; o < inputCount; o++ {
+			inputs.SetVec(o, r.NormFloat64())
+		}
+
+		targets := mat.NewVecDense(outputCount, nil)
+		targets.SetVec(r.Intn(outputCount), 1)
+
+		items[i] = CreateTrainingItem(inputs, targets)
+	}
+
+	return items
+}
+
+// checkGradientResults fails the test if no layers were checked or if any layer's error is too large
+func checkGradientResults(t *testing.T, results []LayerGradientCheck, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 {
+		t.Error("No layers were checked")
+	}
+	for _, result := range results {
+		if result.RelativeError >= GradientCheckTolerance {
+			t.Error(result)
+		}
+	}
+}
+
+// TestGradients checks the gradients of every layer of small dense, convolutional and pooling models
+// with random weights on a batch of random items
+func TestGradients(t *testing.T) {
+	util.SetSeed(1)
+
+	for i, config := range gradientCheckNetworks() {
+		t.Run(fmt.Sprintf("Network %d %v", i, config.LayerSizes), func(t *testing.T) {
+			n, err := CreateNetworkFromConfig(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := n.CheckGradients(randomGradientCheckItems(n.GetInputCount(), n.GetOutputCount(), 5), 1e-5)
+			checkGradientResults(t, results, err)
+		})
+	}
+
+	for i, config := range gradientCheckConvolutions {
+		t.Run(fmt.Sprintf("Convolution %d %s", i, config.InputShape), func(t *testing.T) {
+			conv, err := CreateConv2D(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next, err := CreateConv2D(Conv2DConfig{InputShape: conv.GetOutputShape(), Filters: 2, KernelSize: 2, Padding: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			model := CreateSequential(
+				conv,
+				CreateActivationLayer(Tanh{}),
+				next,
+				CreateActivationLayer(Tanh{}),
+				CreateDense(next.GetOutputShape().Size(), 3),
+				CreateActivationLayer(Softmax{}),
+			)
+			results, err := model.CheckGradients(randomGradientCheckItems(config.InputShape.Size(), 3, 5), 1e-5)
+			checkGradientResults(t, results, err)
+		})
+	}
+
+	pools, err := createGradientCheckPools(gradientCheckPoolShape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, pool := range pools {
+		t.Run(fmt.Sprintf("Pool %d %T", i, pool), func(t *testing.T) {
+			conv, err := CreateConv2D(Conv2DConfig{InputShape: gradientCheckPoolShape, Filters: 2, KernelSize: 3, Padding: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			model := CreateSequential(
+				conv,
+				CreateActivationLayer(Tanh{}),
+				pool,
+				CreateDense(pool.GetOutputShape().Size(), 3),
+				CreateActivationLayer(Softmax{}),
+			)
+			results, err := model.CheckGradients(randomGradientCheckItems(gradientCheckPoolShape.Size(), 3, 5), 1e-5)
+			checkGradientResults(t, results, err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/shimmy568/GoNeuralNetworks/data"
+)
+
+// This file holds ImageShape, which describes the images that are run through the image layers
+
+// ImageShape is the number of channels, height and width of the images a layer takes or returns
+// Every image is one column of the layer's input, stored one channel after another
+// with the pixels of each channel stored one row after another, so pixel (x, y) of channel c is at c*Height*Width + y*Width + x
+type ImageShape struct {
+	Channels, Height, Width int
+}
+
+// GetMonochromeImageShape returns the shape of a single channel image from the data package
+func GetMonochromeImageShape(image *data.MonochromeImageData) ImageShape {
+	return ImageShape{Channels: 1, Height: image.Height, Width: image.Width}
+}
+
+// Size returns the number of values in an image of this shape, which is the number of rows it takes up in a batch
+func (s ImageShape) Size() int {
+	return s.Channels * s.Height * s.Width
+}
+
+// index returns the row of pixel (x, y) of channel c
+func (s ImageShape) index(c, y, x int) int {
+	return (c*s.Height+y)*s.Width + x
+}
+
+// String returns the shape as channels x height x width
+func (s ImageShape) String() string {
+	return fmt.Sprintf("%dx%dx%d", s.Channels, s.Height, s.Width)
+}
+
+// check returns an error if any of the dimensions of the shape aren't positive
+func (s ImageShape) check() error {
+	if s.Channels <= 0 || s.Height <= 0 || s.Width <= 0 {
+		return fmt.Errorf("Image shape %s must have at least one channel, row and column", s)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the initializers used to pick the starting weights of a layer
+
+// Initializer fills a weight matrix with starting values
+// Weight matrices have one row per output (fan out) and one column per input (fan in)
+type Initializer interface {
+	// Initialize fills weights using random numbers from r
+	Initialize(weights *mat.Dense, r *rand.Rand)
+}
+
+// fillUniform fills a matrix with values picked uniformly from [-limit, limit]
+func fillUniform(weights *mat.Dense, r *rand.Rand, limit float64) {
+	weights.Apply(func(i, j int, v float64) float64 {
+		return (r.Float64()*2 - 1) * limit
+	}, weights)
+}
+
+// fillNormal fills a matrix with values picked from a normal distribution with a mean of 0
+func fillNormal(weights *mat.Dense, r *rand.Rand, stdDev float64) {
+	weights.Apply(func(i, j int, v float64) float64 {
+		return r.NormFloat64() * stdDev
+	}, weights)
+}
+
+// fans returns the number of inputs and outputs of a weight matrix
+func fans(weights *mat.Dense) (fanIn float64, fanOut float64) {
+	rows, cols := weights.Dims()
+	return float64(cols), float64(rows)
+}
+
+// FanInUniform picks weights uniformly from [-1/sqrt(fanIn), 1/sqrt(fanIn)], this is what networks have always used
+type FanInUniform struct{}
+
+// Initialize fills the weights
+func (FanInUniform) Initialize(weights *mat.Dense, r *rand.Rand) {
+	fanIn, _ := fans(weights)
+	fillUniform(weights, r, 1/math.Sqrt(fanIn))
+}
+
+// XavierUniform is glorot initialization, uniform over [-sqrt(6/(fanIn+fanOut)), sqrt(6/(fanIn+fanOut))]
+// It works well for sigmoid and tanh layers
+type XavierUniform struct{}
+
+// Initialize fills the weights
+func (XavierUniform) Initialize(weights *mat.Dense, r *rand.Rand) {
+	fanIn, fanOut := fans(weights)
+	fillUniform(weights, r, math.Sqrt(6/(fanIn+fanOut)))
+}
+
+// XavierNormal is glorot initialization, normal with a standard deviation of sqrt(2/(fanIn+fanOut))
+// It works well for sigmoid and tanh layers
+type XavierNormal struct{}
+
+// Initialize fills the weights
+func (XavierNormal) Initialize(weights *mat.Dense, r *rand.Rand) {
+	fanIn, fanOut := fans(weights)
+	fillNormal(weights, r, math.Sqrt(2/(fanIn+fanOut)))
+}
+
+// HeUniform is kaiming initialization, uniform over [-sqrt(6/fanIn), sqrt(6/fanIn)]
+// It works well for ReLU layers
+type HeUniform struct{}
+
+// Initialize fills the weights
+func (HeUniform) Initialize(weights *mat.Dense, r *rand.Rand) {
+	fanIn, _ := fans(weights)
+	fillUniform(weights, r, math.Sqrt(6/fanIn))
+}
+
+// HeNormal is kaiming initialization, normal with a standard deviation of sqrt(2/fanIn)
+// It works well for ReLU layers
+type HeNormal struct{}
+
+// Initialize fills the weights
+func (HeNormal) Initialize(weights *mat.Dense, r *rand.Rand) {
+	fanIn, _ := fans(weights)
+	fillNormal(weights, r, math.Sqrt(2/fanIn))
+}
+
+// LeCunUniform is uniform over [-sqrt(3/fanIn), sqrt(3/fanIn)], it works well for SELU and linear layers
+type LeCunUniform struct{}
+
+// Initialize fills the weights
+func (LeCunUniform) Initialize(weights *mat.Dense, r *rand.Rand) {
+	fanIn, _ := fans(weights)
+	fillUniform(weights, r, math.Sqrt(3/fanIn))
+}
+
+// LeCunNormal is normal with a standard deviation of sqrt(1/fanIn), it works well for SELU and linear layers
+type LeCunNormal struct{}
+
+// Initialize fills the weights
+func (LeCunNormal) Initialize(weights *mat.Dense, r *rand.Rand) {
+	fanIn, _ := fans(weights)
+	fillNormal(weights, r, math.Sqrt(1/fanIn))
+}
+
+// Orthogonal fills the weights with a random orthogonal matrix, so the rows or columns are orthonormal
+// It helps keep gradients from vanishing or exploding in deep networks
+type Orthogonal struct {
+	// Gain scales the orthogonal matrix, a Gain of 0 is treated as 1
+	Gain float64
+}
+
+// Initialize fills the weights with the Q of the QR decomposition of a random normal matrix
+func (o Orthogonal) Initialize(weights *mat.Dense, r *rand.Rand) {
+	rows, cols := weights.Dims()
+
+	// QR needs a matrix that is at least as tall as it is wide, so decompose the transpose for wide matrices
+	tall, short := rows, cols
+	if rows < cols {
+		tall, short = cols, rows
+	}
+	random := mat.NewDense(tall, short, nil)
+	fillNormal(random, r, 1)
+
+	var qr mat.QR
+	qr.Factorize(random)
+	var q, rMat mat.Dense
+	qr.QTo(&q)
+	qr.RTo(&rMat)
+
+	// Multiply each column of Q by the sign of R's diagonal so the result is uniformly distributed
+	gain := o.Gain
+	if gain == 0 {
+		gain = 1
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			row, col := i, j
+			if rows < cols {
+				row, col = j, i
+			}
+
+			sign := 1.0
+			if rMat.At(col, col) < 0 {
+				sign = -1
+			}
+			weights.Set(i, j, gain*sign*q.At(row, col))
+		}
+	}
+}
+
+// Constant sets every weight to the same value
+type Constant struct {
+	Value float64
+}
+
+// Initialize fills the weights
+func (c Constant) Initialize(weights *mat.Dense, r *rand.Rand) {
+	weights.Apply(func(i, j int, v float64) float64 {
+		return c.Value
+	}, weights)
+}
+
+// Zeros sets every weight to 0
+type Zeros struct{}
+
+// Initialize fills the weights
+func (Zeros) Initialize(weights *mat.Dense, r *rand.Rand) {
+	weights.Zero()
+}
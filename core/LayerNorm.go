@@ -0,0 +1,129 @@
+package core
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the layer normalization layer
+
+// LayerNorm is a layer that normalizes the inputs of each sample to have a mean of 0 and a variance of 1
+// and then scales and shifts them by the learned gamma and beta
+// Unlike BatchNorm the statistics come from a single sample, so it acts the same while training and during inference
+// and works when training on one item at a time
+type LayerNorm struct {
+	gamma *Param
+	beta  *Param
+
+	// Epsilon is added to the variance so the layer never divides by zero
+	Epsilon float64
+
+	// xHat and invStd are the normalized input and 1 / sqrt(variance + epsilon) of each sample from the last training call to Forward
+	xHat   *mat.Dense
+	invStd []float64
+}
+
+// CreateLayerNorm creates a layer normalization layer for the given number of inputs
+// gamma starts at 1 and beta at 0 so the layer only normalizes until it has been trained
+func CreateLayerNorm(inputCount int) *LayerNorm {
+	gamma := mat.NewDense(inputCount, 1, nil)
+	for i := 0; i < inputCount; i++ {
+		gamma.Set(i, 0, 1)
+	}
+
+	return &LayerNorm{
+		gamma:   createParam(gamma, false),
+		beta:    createParam(mat.NewDense(inputCount, 1, nil), false),
+		Epsilon: 1e-5,
+	}
+}
+
+// GetGamma returns the learned scale of each input as a column vector
+func (l *LayerNorm) GetGamma() *mat.Dense {
+	return l.gamma.Value
+}
+
+// GetBeta returns the learned shift of each input as a column vector
+func (l *LayerNorm) GetBeta() *mat.Dense {
+	return l.beta.Value
+}
+
+// Forward normalizes every sample over its inputs and then scales and shifts it
+func (l *LayerNorm) Forward(input *mat.Dense, training bool) *mat.Dense {
+	r, c := input.Dims()
+
+	// Find the mean and variance of each sample
+	mean := make([]float64, c)
+	invStd := make([]float64, c)
+	for j := 0; j < c; j++ {
+		sum := 0.0
+		for i := 0; i < r; i++ {
+			sum += input.At(i, j)
+		}
+		mean[j] = sum / float64(r)
+
+		variance := 0.0
+		for i := 0; i < r; i++ {
+			d := input.At(i, j) - mean[j]
+			variance += d * d
+		}
+		invStd[j] = 1 / math.Sqrt(variance/float64(r)+l.Epsilon)
+	}
+
+	// Normalize, scale and shift
+	xHat := apply(func(i, j int, v float64) float64 {
+		return (v - mean[j]) * invStd[j]
+	}, input)
+	output := apply(func(i, j int, v float64) float64 {
+		return l.gamma.Value.At(i, 0)*v + l.beta.Value.At(i, 0)
+	}, xHat)
+
+	if training {
+		l.xHat = xHat
+		l.invStd = invStd
+	}
+
+	return output
+}
+
+// Backward finds the gradients of gamma and beta and returns the gradient with respect to the input
+func (l *LayerNorm) Backward(grad *mat.Dense) *mat.Dense {
+	r, c := grad.Dims()
+	l.gamma.Grad = sumColumns(multiply(grad, l.xHat))
+	l.beta.Grad = sumColumns(grad)
+
+	// With dxHat = gamma * dy, dx = invStd / n * (n * dxHat - sum(dxHat) - xHat * sum(dxHat * xHat)) for each sample
+	n := float64(r)
+	output := mat.NewDense(r, c, nil)
+	for j := 0; j < c; j++ {
+		sum := 0.0
+		sumXHat := 0.0
+		for i := 0; i < r; i++ {
+			d := grad.At(i, j) * l.gamma.Value.At(i, 0)
+			sum += d
+			sumXHat += d * l.xHat.At(i, j)
+		}
+
+		for i := 0; i < r; i++ {
+			d := grad.At(i, j) * l.gamma.Value.At(i, 0)
+			output.Set(i, j, l.invStd[j]/n*(n*d-sum-l.xHat.At(i, j)*sumXHat))
+		}
+	}
+
+	return output
+}
+
+// Params returns gamma and beta
+func (l *LayerNorm) Params() []*Param {
+	return []*Param{l.gamma, l.beta}
+}
+
+// createWorker returns a copy of the layer that shares gamma and beta
+func (l *LayerNorm) createWorker() Layer {
+	return &LayerNorm{
+		gamma:   l.gamma.shareValue(),
+		beta:    l.beta.shareValue(),
+		Epsilon: l.Epsilon,
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/util"
+)
+
+// This file holds the Layer interface and the basic layers that networks are built out of
+
+// Layer is a single step of a network that inputs can be run forwards through and gradients can be run backwards through
+// Inputs, outputs and gradients are matrices with one sample per column
+type Layer interface {
+	// Forward runs a batch of inputs through the layer
+	// training is the mode of the network, it is true while training and false for inference
+	// When training is true the layer keeps whatever it needs for the following call to Backward,
+	// and layers like dropout only change their input in training mode
+	Forward(input *mat.Dense, training bool) *mat.Dense
+
+	// Backward takes the gradient of the loss with respect to the output of the last training call to Forward,
+	// sets the gradients of the layer's params and returns the gradient with respect to the layer's input
+	Backward(grad *mat.Dense) *mat.Dense
+
+	// Params returns the learnable parameters of the layer, or nil if it doesn't have any
+	Params() []*Param
+}
+
+// paramsUpdatedListener is implemented by layers that need to know when their params were changed from outside the layer
+// such as by an optimizer or by loading a model from disk
+type paramsUpdatedListener interface {
+	paramsUpdated()
+}
+
+// statefulLayer is implemented by layers that keep values which aren't learned by the optimizer
+// but still need to be saved with the model, such as the running statistics of batch normalization
+type statefulLayer interface {
+	state() []*mat.Dense
+}
+
+// workerLayer is implemented by layers that can be trained on more than one goroutine at once
+type workerLayer interface {
+	// createWorker returns a copy of the layer for a worker goroutine that shares the values of its params
+	// but has its own gradients, cached values and state, so it can be run at the same time as the original
+	createWorker() Layer
+}
+
+// snapshotLayer is implemented by layers whose worker copy would change the original,
+// such as dropout seeding the copy from its own random number generator
+type snapshotLayer interface {
+	// snapshotCopy returns a copy of the layer that is only used for inference and leaves the original untouched
+	snapshotCopy() Layer
+}
+
+// Dense is a fully connected layer, every output is a weighted sum of every input plus a bias
+type Dense struct {
+	weights *Param
+	biases  *Param
+
+	// input is the input from the last training call to Forward
+	input *mat.Dense
+}
+
+// CreateDense creates a fully connected layer with random weights and biases set to zero
+// The weights are picked uniformly from [-1/sqrt(inputCount), 1/sqrt(inputCount)]
+func CreateDense(inputCount int, outputCount int) *Dense {
+	return CreateDenseWithInitializer(inputCount, outputCount, FanInUniform{})
+}
+
+// CreateDenseWithInitializer creates a fully connected layer with weights from the given initializer and biases set to zero
+func CreateDenseWithInitializer(inputCount int, outputCount int, initializer Initializer) *Dense {
+	return CreateDenseWithRand(inputCount, outputCount, initializer, util.GetRand())
+}
+
+// CreateDenseWithRand creates a fully connected layer with weights from the given initializer and biases set to zero
+// using the given random number generator so the weights can be reproduced
+func CreateDenseWithRand(inputCount int, outputCount int, initializer Initializer, r *rand.Rand) *Dense {
+	weights := mat.NewDense(outputCount, inputCount, nil)
+	initializer.Initialize(weights, r)
+	biases := mat.NewDense(outputCount, 1, nil)
+
+	return &Dense{
+		weights: createParam(weights, true),
+		biases:  createParam(biases, false),
+	}
+}
+
+// GetWeights returns the weight matrix of the layer, it has one row per output and one column per input
+func (d *Dense) GetWeights() *mat.Dense {
+	return d.weights.Value
+}
+
+// GetBiases returns the biases of the layer as a column vector with one row per output
+func (d *Dense) GetBiases() *mat.Dense {
+	return d.biases.Value
+}
+
+// Forward finds weights * input + biases for every sample in the batch
+func (d *Dense) Forward(input *mat.Dense, training bool) *mat.Dense {
+	if training {
+		d.input = input
+	}
+
+	output := dot(d.weights.Value, input)
+	addColumnVector(output, d.biases.Value)
+	return output
+}
+
+// Backward finds the gradient of the weights and biases and returns the gradient with respect to the input
+// The weight gradient sums the gradient of every sample in the batch, losses average over the batch so this is an average
+func (d *Dense) Backward(grad *mat.Dense) *mat.Dense {
+	d.weights.Grad = dot(grad, d.input.T())
+	d.biases.Grad = sumColumns(grad)
+
+	return dot(d.weights.Value.T(), grad)
+}
+
+// Params returns the weights and biases of the layer
+func (d *Dense) Params() []*Param {
+	return []*Param{d.weights, d.biases}
+}
+
+// createWorker returns a copy of the layer that shares its weights and biases
+func (d *Dense) createWorker() Layer {
+	return &Dense{
+		weights: d.weights.shareValue(),
+		biases:  d.biases.shareValue(),
+	}
+}
+
+// ActivationLayer is a layer that applies an activation function to each sample
+type ActivationLayer struct {
+	activation Activation
+
+	// param holds the learned parameter of parametric activations so the optimizer can update it
+	param *Param
+
+	// z and a are the input and output from the last training call to Forward
+	z *mat.Dense
+	a *mat.Dense
+}
+
+// CreateActivationLayer creates a layer that applies the given activation
+// Parametric activations such as PReLU are learned, so each layer should be given its own instance
+func CreateActivationLayer(activation Activation) *ActivationLayer {
+	l := &ActivationLayer{}
+	l.setActivation(activation)
+	return l
+}
+
+// setActivation changes the activation of the layer and creates a param for it if it has a learned parameter
+func (l *ActivationLayer) setActivation(activation Activation) {
+	l.activation = activation
+	l.param = nil
+	if p, ok := activation.(parametricActivation); ok {
+		l.param = createParam(mat.NewDense(1, 1, []float64{p.getParam()}), false)
+	}
+}
+
+// GetActivation returns the activation the layer applies
+func (l *ActivationLayer) GetActivation() Activation {
+	return l.activation
+}
+
+// Forward applies the activation to the input
+func (l *ActivationLayer) Forward(input *mat.Dense, training bool) *mat.Dense {
+	output := l.activation.Forward(input)
+	if training {
+		l.z = input
+		l.a = output
+	}
+
+	return output
+}
+
+// Backward returns the gradient with respect to the input of the activation
+func (l *ActivationLayer) Backward(grad *mat.Dense) *mat.Dense {
+	if p, ok := l.activation.(parametricActivation); ok {
+		l.param.Grad.Set(0, 0, p.paramGradient(l.z, grad))
+	}
+
+	return l.activation.Backward(l.z, l.a, grad)
+}
+
+// Params returns the learned parameter of the activation if it has one
+func (l *ActivationLayer) Params() []*Param {
+	if l.param == nil {
+		return nil
+	}
+	return []*Param{l.param}
+}
+
+// createWorker returns a copy of the layer that shares the value of its param
+// Parametric activations are copied since they hold the param, so paramsUpdated needs to be called
+// on the copy when the shared value changes
+func (l *ActivationLayer) createWorker() Layer {
+	worker := &ActivationLayer{activation: l.activation}
+	if p, ok := l.activation.(parametricActivation); ok {
+		worker.activation = p.copyActivation()
+		worker.param = l.param.shareValue()
+	}
+	return worker
+}
+
+// paramsUpdated copies the updated value of the param back into the activation
+func (l *ActivationLayer) paramsUpdated() {
+	if p, ok := l.activation.(parametricActivation); ok {
+		p.setParam(l.param.Value.At(0, 0))
+	}
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the loss functions that can be used to train a network
+
+// lossEpsilon is used to keep values away from 0 and 1 before taking their log
+const lossEpsilon = 1e-12
+
+// Loss is a function that measures how far the output of a network is from the expected output
+// The output and target matrices have one column per sample
+type Loss interface {
+	// Name returns the name of the loss
+	Name() string
+
+	// Value returns the loss summed over the outputs and averaged over the samples
+	Value(output, target mat.Matrix) float64
+
+	// Gradient returns the gradient of Value with respect to output
+	Gradient(output, target mat.Matrix) *mat.Dense
+}
+
+// sumElementwise sums fn over every pair of output and target values and averages it over the samples
+func sumElementwise(fn func(y, t float64) float64, output, target mat.Matrix) float64 {
+	r, c := output.Dims()
+	sum := 0.0
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			sum += fn(output.At(i, j), target.At(i, j))
+		}
+	}
+
+	return sum / float64(c)
+}
+
+// gradientElementwise applies the derivative fn to every pair of output and target values and averages it over the samples
+func gradientElementwise(fn func(y, t float64) float64, output, target mat.Matrix) *mat.Dense {
+	_, c := output.Dims()
+	return apply(func(i, j int, y float64) float64 {
+		return fn(y, target.At(i, j)) / float64(c)
+	}, output)
+}
+
+// clampProbability keeps a probability away from exactly 0 and 1 so that its log is finite
+func clampProbability(y float64) float64 {
+	return math.Min(math.Max(y, lossEpsilon), 1-lossEpsilon)
+}
+
+// MeanSquaredError is half of the squared difference between the output and the target
+// This is the loss that the network has always been trained with, it is used for regression
+type MeanSquaredError struct{}
+
+// Name returns the name of the loss
+func (MeanSquaredError) Name() string { return "mse" }
+
+// Value returns half of the sum of the squared errors
+func (MeanSquaredError) Value(output, target mat.Matrix) float64 {
+	return sumElementwise(func(y, t float64) float64 {
+		return 0.5 * (y - t) * (y - t)
+	}, output, target)
+}
+
+// Gradient returns output - target
+func (MeanSquaredError) Gradient(output, target mat.Matrix) *mat.Dense {
+	return gradientElementwise(func(y, t float64) float64 {
+		return y - t
+	}, output, target)
+}
+
+// CategoricalCrossEntropy is the loss for classifying each sample into exactly one class
+// It expects the outputs to be probabilities that sum to 1, so it should be used with a softmax output layer
+type CategoricalCrossEntropy struct{}
+
+// Name returns the name of the loss
+func (CategoricalCrossEntropy) Name() string { return "categoricalcrossentropy" }
+
+// Value returns -sum(target * log(output))
+func (CategoricalCrossEntropy) Value(output, target mat.Matrix) float64 {
+	return sumElementwise(func(y, t float64) float64 {
+		return -t * math.Log(clampProbability(y))
+	}, output, target)
+}
+
+// Gradient returns -target / output
+func (CategoricalCrossEntropy) Gradient(output, target mat.Matrix) *mat.Dense {
+	return gradientElementwise(func(y, t float64) float64 {
+		return -t / clampProbability(y)
+	}, output, target)
+}
+
+// BinaryCrossEntropy is the loss for multi-label problems where each output is an independent yes or no
+// It expects each output to be a probability, so it should be used with a sigmoid output layer
+type BinaryCrossEntropy struct{}
+
+// Name returns the name of the loss
+func (BinaryCrossEntropy) Name() string { return "binarycrossentropy" }
+
+// Value returns -sum(target * log(output) + (1 - target) * log(1 - output))
+func (BinaryCrossEntropy) Value(output, target mat.Matrix) float64 {
+	return sumElementwise(func(y, t float64) float64 {
+		y = clampProbability(y)
+		return -t*math.Log(y) - (1-t)*math.Log(1-y)
+	}, output, target)
+}
+
+// Gradient returns (output - target) / (output * (1 - output))
+func (BinaryCrossEntropy) Gradient(output, target mat.Matrix) *mat.Dense {
+	return gradientElementwise(func(y, t float64) float64 {
+		y = clampProbability(y)
+		return (y - t) / (y * (1 - y))
+	}, output, target)
+}
+
+// Huber is a regression loss that is squared for small errors and linear for large ones so outliers matter less
+type Huber struct {
+	// Delta is the size of error where the loss switches from squared to linear
+	Delta float64
+}
+
+// Name returns the name of the loss
+func (h Huber) Name() string { return "huber" }
+
+// Value returns 0.5 * error^2 for errors smaller than delta and delta * (|error| - 0.5 * delta) for the rest
+func (h Huber) Value(output, target mat.Matrix) float64 {
+	return sumElementwise(func(y, t float64) float64 {
+		e := math.Abs(y - t)
+		if e <= h.Delta {
+			return 0.5 * e * e
+		}
+		return h.Delta * (e - 0.5*h.Delta)
+	}, output, target)
+}
+
+// Gradient returns the error clipped to between -delta and delta
+func (h Huber) Gradient(output, target mat.Matrix) *mat.Dense {
+	return gradientElementwise(func(y, t float64) float64 {
+		return math.Max(-h.Delta, math.Min(h.Delta, y-t))
+	}, output, target)
+}
+
+// Hinge is the margin based loss used by support vector machines, each output is scored as a separate -1 or 1 label
+// Targets of 0 are treated as -1 so the same one hot targets as the other losses can be used
+// It should be used with a linear output layer
+type Hinge struct{}
+
+// Name returns the name of the loss
+func (Hinge) Name() string { return "hinge" }
+
+// Value returns max(0, 1 - target * output)
+func (Hinge) Value(output, target mat.Matrix) float64 {
+	return sumElementwise(func(y, t float64) float64 {
+		return math.Max(0, 1-hingeLabel(t)*y)
+	}, output, target)
+}
+
+// Gradient returns -target where the output is inside the margin and 0 everywhere else
+func (Hinge) Gradient(output, target mat.Matrix) *mat.Dense {
+	return gradientElementwise(func(y, t float64) float64 {
+		t = hingeLabel(t)
+		if t*y < 1 {
+			return -t
+		}
+		return 0
+	}, output, target)
+}
+
+// hingeLabel converts a target to the -1 or 1 label used by the hinge loss
+func hingeLabel(t float64) float64 {
+	if t <= 0 {
+		return -1
+	}
+	return 1
+}
+
+// Focal is a binary cross entropy that down weights outputs that are already classified well
+// so training focuses on the hard examples, it should be used with a sigmoid output layer
+type Focal struct {
+	// Gamma controls how much easy examples are down weighted, 2 is common and 0 is plain binary cross entropy
+	Gamma float64
+
+	// Alpha is the weight given to positive targets and 1 - Alpha is given to negative ones
+	// An Alpha of 0 weights both the same
+	Alpha float64
+}
+
+// Name returns the name of the loss
+func (f Focal) Name() string { return "focal" }
+
+// weights returns the weight of the positive and the negative part of the loss
+func (f Focal) weights() (float64, float64) {
+	if f.Alpha == 0 {
+		return 1, 1
+	}
+	return f.Alpha, 1 - f.Alpha
+}
+
+// Value returns -alpha * target * (1 - output)^gamma * log(output) - (1 - alpha) * (1 - target) * output^gamma * log(1 - output)
+func (f Focal) Value(output, target mat.Matrix) float64 {
+	pos, neg := f.weights()
+	return sumElementwise(func(y, t float64) float64 {
+		y = clampProbability(y)
+		return -pos*t*math.Pow(1-y, f.Gamma)*math.Log(y) - neg*(1-t)*math.Pow(y, f.Gamma)*math.Log(1-y)
+	}, output, target)
+}
+
+// Gradient returns the derivative of Value with respect to the output
+func (f Focal) Gradient(output, target mat.Matrix) *mat.Dense {
+	pos, neg := f.weights()
+	return gradientElementwise(func(y, t float64) float64 {
+		y = clampProbability(y)
+		posGrad := pos * t * (f.Gamma*math.Pow(1-y, f.Gamma-1)*math.Log(y) - math.Pow(1-y, f.Gamma)/y)
+		negGrad := -neg * (1 - t) * (f.Gamma*math.Pow(y, f.Gamma-1)*math.Log(1-y) - math.Pow(y, f.Gamma)/(1-y))
+		return posGrad + negGrad
+	}, output, target)
+}
+
+// fusedOutputGradient finds the gradient of the loss with respect to the input of the output layer's activation directly
+// Softmax with categorical cross entropy and sigmoid with binary cross entropy both simplify to (output - target),
+// which is much more numerically stable than going through the activation. ok is false for any other pairing
+func fusedOutputGradient(loss Loss, activation Activation, output, target mat.Matrix) (gradient *mat.Dense, ok bool) {
+	_, softmax := activation.(Softmax)
+	_, categorical := loss.(CategoricalCrossEntropy)
+	_, sigmoidOutput := activation.(Sigmoid)
+	_, binary := loss.(BinaryCrossEntropy)
+
+	if !(softmax && categorical) && !(sigmoidOutput && binary) {
+		return nil, false
+	}
+
+	_, c := output.Dims()
+	gradient = subtract(output, target)
+	gradient.Scale(1/float64(c), gradient)
+	return gradient, true
+}
+
+// defaultLoss returns the loss used when none is given, cross entropy for softmax outputs and squared error otherwise
+func defaultLoss(outputActivation Activation) Loss {
+	if _, ok := outputActivation.(Softmax); ok {
+		return CategoricalCrossEntropy{}
+	}
+	return MeanSquaredError{}
+}
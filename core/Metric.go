@@ -0,0 +1,52 @@
+package core
+
+import (
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/metrics"
+)
+
+// This file holds the metrics that can be tracked while fitting a network
+
+// Metric is a measure of how well the output of a network matches the expected output that is tracked while fitting
+// Unlike a loss it doesn't need a gradient, the output and target matrices have one column per sample
+type Metric interface {
+	// Name returns the name the metric is stored under in the history
+	Name() string
+
+	// Value returns the metric over all the samples
+	Value(output, target mat.Matrix) float64
+}
+
+// Accuracy is the fraction of samples that were classified correctly
+// Networks with more than one output are scored by which output is the highest, and networks with
+// a single output are scored by whether the output and the target are on the same side of 0.5
+type Accuracy struct{}
+
+// Name returns the name of the metric
+func (Accuracy) Name() string { return "accuracy" }
+
+// Value returns the fraction of samples where the predicted class matches the target
+func (Accuracy) Value(output, target mat.Matrix) float64 {
+	r, c := output.Dims()
+	if c == 0 {
+		return 0
+	}
+
+	correct := 0
+	for j := 0; j < c; j++ {
+		if r == 1 {
+			if (output.At(0, j) >= 0.5) == (target.At(0, j) >= 0.5) {
+				correct++
+			}
+			continue
+		}
+
+		// Use the same argmax as the metrics package so this matches the accuracy in its reports
+		if metrics.ArgMax(mat.Col(nil, j, output)) == metrics.ArgMax(mat.Col(nil, j, target)) {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(c)
+}
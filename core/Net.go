@@ -5,58 +5,166 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"math"
 	"os"
-	"strconv"
 	"strings"
 
 	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/util"
 )
 
 // NeuralNet is a data type that is used to preform basic neural network operations
+// It is a fully connected network built on top of a Sequential of Dense and ActivationLayer layers
 type NeuralNet struct {
 	// Importent values about network
-	inputCount      int
-	outputCount     int
-	hiddenLayers    int
-	hiddenLayerSize int
+	inputCount   int
+	outputCount  int
+	hiddenLayers int
+
+	// layerSizes holds the number of nodes in every layer, starting with the input layer and ending with the output layer
+	layerSizes []int
 
 	// LearningRate is the learning rate used when training the network
+	// If the network has a scheduler this is the base rate that it changes, use GetLearningRate for the current rate
 	LearningRate float64
 
-	// Weights of the network
-	weights []*mat.Dense
+	// model is the sequential network that does all the work, it alternates between a dense layer and an activation layer
+	// with batch or layer normalization between them and dropout after them in hidden layers that use them
+	// denseLayers, activationLayers and the normalization layers hold the same layers so they can be accessed directly
+	model            *Sequential
+	denseLayers      []*Dense
+	activationLayers []*ActivationLayer
+	batchNormLayers  []*BatchNorm
+	layerNormLayers  []*LayerNorm
 }
 
-// sigmoid is an implemention of the sigmoid function for use in .Apply on a matrix of data
-func sigmoid(value float64) float64 {
-	return math.Pow(math.E, value) / (math.Pow(math.E, value) + 1)
+// CreateNetwork is a function to create up a neural network where every hidden layer is the same size
+func CreateNetwork(inputCount int, outputCount int, hiddenLayers int, hiddenLayerSize int, learningRate float64) NeuralNet {
+	// Build the list of layer sizes, input layer first and output layer last
+	layerSizes := []int{inputCount}
+	for i := 0; i < hiddenLayers; i++ {
+		layerSizes = append(layerSizes, hiddenLayerSize)
+	}
+	layerSizes = append(layerSizes, outputCount)
+
+	n, err := CreateNetworkWithLayers(layerSizes, learningRate)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
 }
 
-// CreateNetwork is a function to create up a neural network
-func CreateNetwork(inputCount int, outputCount int, hiddenLayers int, hiddenLayerSize int, learningRate float64) NeuralNet {
+// CreateNetworkWithLayers creates a neural network with the given number of nodes in each layer
+// layerSizes starts with the input layer and ends with the output layer, so []int{784, 512, 128, 10}
+// is a network with two hidden layers
+func CreateNetworkWithLayers(layerSizes []int, learningRate float64) (NeuralNet, error) {
+	return CreateNetworkFromConfig(NetworkConfig{
+		LayerSizes:   layerSizes,
+		LearningRate: learningRate,
+	})
+}
+
+// CreateNetworkFromConfig creates a neural network using the options in the given config
+func CreateNetworkFromConfig(config NetworkConfig) (NeuralNet, error) {
 	n := NeuralNet{}
+	layerSizes := config.LayerSizes
+
+	// Check that the topology is valid
+	if len(layerSizes) < 2 {
+		return n, errors.New("A network needs at least an input and an output layer")
+	}
+	for i := range layerSizes {
+		if layerSizes[i] <= 0 {
+			return n, errors.New("Every layer in the network needs at least one node")
+		}
+	}
+
+	// Check that there is an activation and an initializer for every layer
+	if config.Activations != nil && len(config.Activations) != len(layerSizes)-1 {
+		return n, errors.New("Number of activations doesn't match the number of layers in the network")
+	}
+	if config.Initializers != nil && len(config.Initializers) != len(layerSizes)-1 {
+		return n, errors.New("Number of initializers doesn't match the number of layers in the network")
+	}
+	if config.DropoutRates != nil && len(config.DropoutRates) != len(layerSizes)-2 {
+		return n, errors.New("Number of dropout rates doesn't match the number of hidden layers in the network")
+	}
+	if config.BatchNorm && config.LayerNorm {
+		return n, errors.New("A network can't use both batch normalization and layer normalization")
+	}
+	for i := range config.DropoutRates {
+		if config.DropoutRates[i] < 0 || config.DropoutRates[i] >= 1 {
+			return n, errors.New("Dropout rates must be in the range [0, 1)")
+		}
+	}
 
-	n.weights = make([]*mat.Dense, hiddenLayers+1)
 	// Copy relevant values to struct
-	n.inputCount = inputCount
-	n.outputCount = outputCount
-	n.hiddenLayers = hiddenLayers
-	n.hiddenLayerSize = hiddenLayerSize
-	n.LearningRate = learningRate
-
-	// Generate random weights for network
-	for i := 0; i < len(n.weights); i++ {
-		if i == 0 {
-			n.weights[i] = mat.NewDense(hiddenLayerSize, inputCount, generateWeights(hiddenLayerSize, inputCount))
-		} else if i == len(n.weights)-1 {
-			n.weights[i] = mat.NewDense(outputCount, hiddenLayerSize, generateWeights(outputCount, hiddenLayerSize))
-		} else {
-			n.weights[i] = mat.NewDense(hiddenLayerSize, hiddenLayerSize, generateWeights(hiddenLayerSize, hiddenLayerSize))
+	n.layerSizes = make([]int, len(layerSizes))
+	copy(n.layerSizes, layerSizes)
+	n.inputCount = layerSizes[0]
+	n.outputCount = layerSizes[len(layerSizes)-1]
+	n.hiddenLayers = len(layerSizes) - 2
+	n.LearningRate = config.LearningRate
+
+	r := config.Rand
+	if r == nil {
+		r = util.GetRand()
+	}
+
+	// Create the layers of the network, layer i maps layerSizes[i] nodes to layerSizes[i+1] nodes
+	// and uses sigmoid and the fan in uniform initializer if none were given
+	var layers []Layer
+	for i := 0; i < len(layerSizes)-1; i++ {
+		var activation Activation = Sigmoid{}
+		if config.Activations != nil {
+			activation = config.Activations[i]
+		}
+
+		var initializer Initializer = FanInUniform{}
+		if config.Initializers != nil {
+			initializer = config.Initializers[i]
+		}
+
+		dense := CreateDenseWithRand(layerSizes[i], layerSizes[i+1], initializer, r)
+		activationLayer := CreateActivationLayer(activation)
+
+		n.denseLayers = append(n.denseLayers, dense)
+		n.activationLayers = append(n.activationLayers, activationLayer)
+		layers = append(layers, dense)
+
+		// Normalize the hidden layers before their activation if batch or layer normalization is turned on
+		if config.BatchNorm && i < len(layerSizes)-2 {
+			batchNorm := CreateBatchNorm(layerSizes[i+1])
+			n.batchNormLayers = append(n.batchNormLayers, batchNorm)
+			layers = append(layers, batchNorm)
+		}
+		if config.LayerNorm && i < len(layerSizes)-2 {
+			layerNorm := CreateLayerNorm(layerSizes[i+1])
+			n.layerNormLayers = append(n.layerNormLayers, layerNorm)
+			layers = append(layers, layerNorm)
+		}
+		layers = append(layers, activationLayer)
+
+		// Add dropout after the hidden layers that have a dropout rate, each gets its own seed based on DropoutSeed
+		if i < len(config.DropoutRates) && config.DropoutRates[i] > 0 {
+			seed := config.DropoutSeed + int64(i)
+			if config.DropoutSeed == 0 {
+				seed = r.Int63()
+			}
+			layers = append(layers, CreateDropoutWithSeed(config.DropoutRates[i], seed))
 		}
 	}
 
-	return n
+	n.model = CreateSequential(layers...)
+	n.model.Loss = config.Loss
+	n.model.Optimizer = config.Optimizer
+	n.model.Regularizer = config.Regularizer
+	n.model.WeightDecay = config.WeightDecay
+	n.model.Clipper = config.Clipper
+	n.model.Scheduler = config.Scheduler
+
+	return n, nil
 }
 
 // GetInputCount returns the number of input nodes for the network
@@ -74,41 +182,102 @@ func (n *NeuralNet) GetHiddenLayerCount() int {
 	return n.hiddenLayers
 }
 
-// GetHiddenLayerSize the size of the hidden layers in the network
+// GetHiddenLayerSize the size of the first hidden layer in the network, or 0 if there are no hidden layers
+// Use GetLayerSizes for networks where the hidden layers aren't all the same size
 func (n *NeuralNet) GetHiddenLayerSize() int {
-	return n.hiddenLayerSize
+	if n.hiddenLayers == 0 {
+		return 0
+	}
+	return n.layerSizes[1]
+}
+
+// GetLayerSizes returns the number of nodes in each layer of the network, from the input layer to the output layer
+func (n *NeuralNet) GetLayerSizes() []int {
+	output := make([]int, len(n.layerSizes))
+	copy(output, n.layerSizes)
+	return output
+}
+
+// GetActivations returns the activation function used by each layer of the network after the input layer
+func (n *NeuralNet) GetActivations() []Activation {
+	output := make([]Activation, len(n.activationLayers))
+	for i := range n.activationLayers {
+		output[i] = n.activationLayers[i].GetActivation()
+	}
+	return output
+}
+
+// GetModel returns the sequential network the NeuralNet is built on
+func (n *NeuralNet) GetModel() *Sequential {
+	return n.model
+}
+
+// GetLoss returns the loss function the network is trained with
+// If no loss was given when the network was created this picks the one that fits the output layer
+func (n *NeuralNet) GetLoss() Loss {
+	return n.model.GetLoss()
+}
+
+// GetOptimizer returns the optimizer used to update the weights of the network
+func (n *NeuralNet) GetOptimizer() Optimizer {
+	return n.model.getOptimizer()
+}
+
+// GetLastLoss returns the loss from the last call to Train, or the average loss from the last call to TrainMultiple
+func (n *NeuralNet) GetLastLoss() float64 {
+	return n.model.GetLastLoss()
+}
+
+// GetLearningRate returns the learning rate that the next update will use, which is LearningRate changed by the scheduler
+func (n *NeuralNet) GetLearningRate() float64 {
+	n.model.LearningRate = n.LearningRate
+	return n.model.GetLearningRate()
+}
+
+// EndEpoch marks the end of an epoch of training for schedulers that change the learning rate every epoch
+// metric is given to schedulers that use one such as ReduceOnPlateau, usually it is the validation loss
+func (n *NeuralNet) EndEpoch(metric float64) {
+	n.model.EndEpoch(metric)
+}
+
+// GetClipCount returns the number of training steps where the gradients were clipped
+func (n *NeuralNet) GetClipCount() int {
+	return n.model.GetClipCount()
 }
 
 // SaveWeights save the weights of the network to a file on disk
 func (n *NeuralNet) SaveWeights(path string) error {
-	dataFile, _ := os.OpenFile(path, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0662)
+	dataFile, err := os.OpenFile(path, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0662)
+	if err != nil {
+		return err
+	}
 	writer := bufio.NewWriter(dataFile)
 	defer dataFile.Close()
 
 	// Write network metadata in first row of csv
-	inputCount := strconv.Itoa(n.inputCount)
-	outputCount := strconv.Itoa(n.outputCount)
-	hiddenLayerCount := strconv.Itoa(n.hiddenLayers)
-	hiddenLayerSize := strconv.Itoa(n.hiddenLayerSize)
-	info := inputCount + "," + outputCount + "," + hiddenLayerCount + "," + hiddenLayerSize + "\n"
-	writer.Write([]byte(info))
-
-	// Convert all layers of network to string array
-	for i := range n.weights {
-		r, c := n.weights[i].Dims()
-		var str strings.Builder
-		for curRow := 0; curRow < r; curRow++ {
-			for curCol := 0; curCol < c; curCol++ {
-				str.WriteString(fmt.Sprintf("%f,", n.weights[i].At(curRow, curCol)))
-			}
-		}
+	writer.Write([]byte(layerSizesToMetadataLine(n.layerSizes)))
+	writer.Write([]byte(activationsToHeaderLine(n.GetActivations())))
+	if len(n.batchNormLayers) > 0 {
+		writer.Write([]byte(batchNormHeaderTag + "\n"))
+	}
+	if len(n.layerNormLayers) > 0 {
+		writer.Write([]byte(layerNormHeaderTag + "\n"))
+	}
 
-		// Write the layer of weights to the csv
-		str.WriteByte('\n')
-		writer.Write([]byte(str.String()))
+	// Write each layer of weights to the csv, followed by each layer of biases
+	for i := range n.denseLayers {
+		writer.Write([]byte(denseToCSVLine(n.denseLayers[i].GetWeights())))
+	}
+	for i := range n.denseLayers {
+		writer.Write([]byte(denseToCSVLine(n.denseLayers[i].GetBiases())))
 	}
 
-	return nil
+	// Write the values of each normalization layer
+	for _, m := range n.normalizationValues() {
+		writer.Write([]byte(denseToCSVLine(m)))
+	}
+
+	return writer.Flush()
 }
 
 // LoadWeights load the weights of the network from a file on disk
@@ -121,101 +290,146 @@ func (n *NeuralNet) LoadWeights(path string) error {
 		return err
 	}
 
-	// The array that holds the parsed metadata
-	// Spots in the array are as follows [inputCount, outputCount, hiddenLayerCount, hiddenLayerSize]
-	splitData := strings.Split(string(metaDataLine), ",")
-	metadata := make([]int, 4)
-	for i := range splitData {
-		metadata[i], err = strconv.Atoi(splitData[i])
-		if err != nil {
-			return err
-		}
+	// Parse the layer sizes out of the metadata
+	layerSizes, err := parseMetadataLine(metaDataLine)
+	if err != nil {
+		return err
 	}
 
 	// make sure the loaded data matches the dims of the network we are loading it into
-	if metadata[0] != n.inputCount {
+	if layerSizes[0] != n.inputCount {
 		return errors.New("Input count of loaded model doesn't match the network")
 	}
 
-	if metadata[1] != n.outputCount {
+	if layerSizes[len(layerSizes)-1] != n.outputCount {
 		return errors.New("Output count of loaded model doesn't match the network")
 	}
 
-	if metadata[2] != n.hiddenLayers {
+	if len(layerSizes) != len(n.layerSizes) {
 		return errors.New("Hidden layer count doesn't match the network")
 	}
 
-	if metadata[3] != n.hiddenLayerSize {
-		return errors.New("Hidden layer size doesn't match the network")
+	for i := range layerSizes {
+		if layerSizes[i] != n.layerSizes[i] {
+			return errors.New("Hidden layer size doesn't match the network")
+		}
+	}
+
+	// Read the rest of the header, models saved before activations were stored in the file only used sigmoid
+	header, dataStart := readModelHeader(lines)
+	activations := make([]Activation, len(n.activationLayers))
+	for i := range activations {
+		activations[i] = Sigmoid{}
+	}
+	if names, ok := header[activationsHeaderTag]; ok {
+		if len(names) != len(activations) {
+			return errors.New("Number of activations in loaded model doesn't match the network")
+		}
+		for i := range names {
+			activations[i], err = activationFromName(names[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Models saved with batch normalization can only be loaded into networks that use it and the other way around
+	if _, ok := header[batchNormHeaderTag]; ok != (len(n.batchNormLayers) > 0) {
+		return errors.New("Batch normalization of loaded model doesn't match the network")
+	}
+	if _, ok := header[layerNormHeaderTag]; ok != (len(n.layerNormLayers) > 0) {
+		return errors.New("Layer normalization of loaded model doesn't match the network")
 	}
 
 	// Read and parse each layer into the network
-	for i := 0; i < n.hiddenLayers+1; i++ {
-		line := lines[i+1]
+	lines = lines[dataStart:]
+	layerCount := len(n.denseLayers)
+	if len(lines) < layerCount {
+		return errors.New("Model file is missing layers of weights")
+	}
+	for i := 0; i < layerCount; i++ {
+		err = parseCSVLineIntoDense(lines[i], n.denseLayers[i].GetWeights())
 		if err != nil {
 			return err
 		}
-
-		layerData := strings.Split(string(line), ",")
-		// Load each value for the current layer into the network
-		r, c := n.weights[i].Dims()
-		for curRow := 0; curRow < r; curRow++ {
-			for curCol := 0; curCol < c; curCol++ {
-				// Parse the float and check for error
-				val, err := strconv.ParseFloat(layerData[(curRow*c)+curCol], 64)
-				if err != nil {
-					return err
-				}
-
-				// Save the weight to the array
-				n.weights[i].Set(curRow, curCol, val)
-			}
-		}
 	}
 
-	return nil
-}
+	// Read the biases, models saved before biases were added don't have them so they are left at zero
+	for i := 0; i < layerCount; i++ {
+		line := ""
+		if len(lines) > layerCount+i {
+			line = lines[layerCount+i]
+		}
 
-// Predict takes a set of input data and generates a set of output values
-func (n *NeuralNet) Predict(inputData []float64) *mat.VecDense {
+		if line == "" {
+			n.denseLayers[i].GetBiases().Zero()
+			continue
+		}
 
-	inputs := mat.NewDense(len(inputData), 1, inputData)
-	var hiddenInputs mat.Matrix
-	var hiddenOutputs mat.Matrix
+		err = parseCSVLineIntoDense(line, n.denseLayers[i].GetBiases())
+		if err != nil {
+			return err
+		}
+	}
 
-	for i := 0; i < n.hiddenLayers+1; i++ {
-		if i == 0 {
-			hiddenInputs = dot(n.weights[i], inputs)
-		} else {
-			hiddenInputs = dot(n.weights[i], hiddenOutputs)
+	// Read the values of the normalization layers which come after the biases
+	values := n.normalizationValues()
+	if len(values) > 0 && len(lines) < 2*layerCount+len(values) {
+		return errors.New("Model file is missing normalization values")
+	}
+	for i := range values {
+		err = parseCSVLineIntoDense(lines[2*layerCount+i], values[i])
+		if err != nil {
+			return err
 		}
+	}
 
-		hiddenOutputs = apply(sigmoidWrapper, hiddenInputs)
+	// Swap in the loaded activations
+	for i := range activations {
+		n.activationLayers[i].setActivation(activations[i])
 	}
 
-	// Copy data to VecDense object
-	output := mat.NewVecDense(n.outputCount, nil)
-	for i := 0; i < n.outputCount; i++ {
-		output.SetVec(i, hiddenOutputs.At(i, 0))
+	return nil
+}
+
+// normalizationValues returns the values of every normalization layer in the order they are stored in model files
+// Batch normalization layers store gamma, beta, the running mean and the running variance
+// and layer normalization layers store gamma and beta
+func (n *NeuralNet) normalizationValues() []*mat.Dense {
+	var values []*mat.Dense
+	for _, b := range n.batchNormLayers {
+		values = append(values, b.GetGamma(), b.GetBeta(), b.GetRunningMean(), b.GetRunningVariance())
+	}
+	for _, l := range n.layerNormLayers {
+		values = append(values, l.GetGamma(), l.GetBeta())
 	}
+	return values
+}
 
-	return output
+// Predict takes a set of input data and generates a set of output values
+func (n *NeuralNet) Predict(inputData []float64) *mat.VecDense {
+	return n.model.Predict(inputData)
 }
 
-// sigmoidPrime applies a inverse of the sigmoid function to a matrix
-func sigmoidPrime(m mat.Matrix) mat.Matrix {
-	rows, _ := m.Dims()
-	o := make([]float64, rows)
-	for i := range o {
-		o[i] = 1
+// PredictBatch runs a matrix of inputs with one sample per column through the network
+// and returns a matrix of outputs with one sample per column
+func (n *NeuralNet) PredictBatch(inputs mat.Matrix) (*mat.Dense, error) {
+	r, _ := inputs.Dims()
+	if r != n.inputCount {
+		return nil, fmt.Errorf("Input dimension for batch doesn't match network's")
 	}
-	ones := mat.NewDense(rows, 1, o)
-	return multiply(m, subtract(ones, m)) // m * (1 - m)
+
+	return n.model.PredictBatch(inputs), nil
 }
 
-// Train is a function that is for one iteration of training using backpropagation
-func (n *NeuralNet) Train(item *TrainingItem) error {
-	// Check training item matches network
+// Snapshot makes a read only copy of the network that can be used for inference from many goroutines
+// while the network keeps training, see Sequential.Snapshot
+func (n *NeuralNet) Snapshot() (*Snapshot, error) {
+	return n.model.Snapshot()
+}
+
+// checkItem checks that a training item has the right number of inputs and expected outputs for the network
+func (n *NeuralNet) checkItem(item *TrainingItem) error {
 	if n.inputCount != len(item.inputData) {
 		return fmt.Errorf("Input dimension for training data doesn't match network's")
 	}
@@ -223,62 +437,103 @@ func (n *NeuralNet) Train(item *TrainingItem) error {
 		return fmt.Errorf("Output dimension for training data doesn't match network's")
 	}
 
-	var hiddenInputData mat.Matrix
-	hiddenOutputData := make([]mat.Matrix, n.hiddenLayers+1)
+	return nil
+}
 
-	input := mat.NewDense(n.inputCount, 1, item.inputData)
+// Train is a function that is for one iteration of training using backpropagation
+func (n *NeuralNet) Train(item *TrainingItem) error {
+	// Check training item matches network
+	err := n.checkItem(item)
+	if err != nil {
+		return err
+	}
+
+	n.model.LearningRate = n.LearningRate
+	return n.model.Train(item)
+}
+
+// TrainMultiple is a function that trains the network given a set of training data
+func (n *NeuralNet) TrainMultiple(trainingData []*TrainingItem) error {
+	totalLoss := 0.0
+	for i := 0; i < len(trainingData); i++ {
+		err := n.Train(trainingData[i])
 
-	// Do the forward propagation step and store all the results from each layer for the backpropagation step
-	for i := 0; i < n.hiddenLayers+1; i++ {
-		if i == 0 {
-			hiddenInputData = dot(n.weights[i], input)
-		} else {
-			hiddenInputData = dot(n.weights[i], hiddenOutputData[i-1])
+		if err != nil {
+			return err
 		}
+		totalLoss += n.model.lastLoss
+	}
 
-		hiddenOutputData[i] = apply(sigmoidWrapper, hiddenInputData)
+	// Keep track of the average loss over all the items
+	if len(trainingData) > 0 {
+		n.model.lastLoss = totalLoss / float64(len(trainingData))
 	}
 
-	// Init arrays and find the error for the output layer of the network
-	targets := mat.NewDense(len(item.expectedOutput), 1, item.expectedOutput)
-	errors := make([]mat.Matrix, n.hiddenLayers+1)
-	errors[n.hiddenLayers] = subtract(targets, hiddenOutputData[len(hiddenOutputData)-1]) // Find error for output layer
+	return nil
+}
 
-	// Find the errors for the rest of the layers
-	for i := 0; i < n.hiddenLayers; i++ {
-		// Take the dot product of the weights and the error from the previous layer
-		errors[n.hiddenLayers-(1+i)] = dot(n.weights[n.hiddenLayers-i].T(), errors[n.hiddenLayers-i])
+// TrainBatch trains the network on a set of training data in mini-batches of batchSize items
+// Each batch is run through the network as one matrix and the weights are updated once per batch
+// using the gradient averaged over the batch, the last batch is smaller if the items don't divide evenly
+func (n *NeuralNet) TrainBatch(trainingData []*TrainingItem, batchSize int) error {
+	// Check all the items match network before any training is done
+	for i := range trainingData {
+		err := n.checkItem(trainingData[i])
+		if err != nil {
+			return fmt.Errorf("Training item %d: %v", i, err)
+		}
 	}
 
-	// Do the actual backpropagation for the weights
-	for i := 0; i < n.hiddenLayers+1; i++ {
+	n.model.LearningRate = n.LearningRate
+	return n.model.TrainBatch(trainingData, batchSize)
+}
 
-		// Find what the input for the layer is
-		var layerInput mat.Matrix
-		if i == 0 { // If it's the first layer use the inputs for the network
-			layerInput = input
-		} else { // If it's any other layer use the output from the previous layer
-			layerInput = hiddenOutputData[i-1]
+// Fit trains the network on the training data for the number of epochs in the config and returns the history of every epoch
+func (n *NeuralNet) Fit(trainingData []*TrainingItem, config FitConfig) (History, error) {
+	// Check all the items match network before any training is done
+	for i := range trainingData {
+		err := n.checkItem(trainingData[i])
+		if err != nil {
+			return History{}, fmt.Errorf("Training item %d: %v", i, err)
+		}
+	}
+	for i := range config.ValidationData {
+		err := n.checkItem(config.ValidationData[i])
+		if err != nil {
+			return History{}, fmt.Errorf("Validation item %d: %v", i, err)
 		}
-
-		delta := dot(multiply(errors[i], sigmoidPrime(hiddenOutputData[i])),
-			layerInput.T())
-		delta.Scale(n.LearningRate, delta)
-		n.weights[i].Add(n.weights[i], delta)
 	}
 
-	return nil
+	// Keep any change callbacks made to the learning rate
+	n.model.LearningRate = n.LearningRate
+	history, err := n.model.Fit(trainingData, config)
+	n.LearningRate = n.model.LearningRate
+
+	return history, err
 }
 
-// TrainMultiple is a function that trains the network given a set of training data
-func (n *NeuralNet) TrainMultiple(trainingData []*TrainingItem) error {
-	for i := 0; i < len(trainingData); i++ {
-		err := n.Train(trainingData[i])
+// CheckGradients compares the gradients found by backpropagation on the items with finite differences
+// and returns the relative error of each layer, see Sequential.CheckGradients
+func (n *NeuralNet) CheckGradients(items []*TrainingItem, epsilon float64) ([]LayerGradientCheck, error) {
+	for i := range items {
+		err := n.checkItem(items[i])
+		if err != nil {
+			return nil, fmt.Errorf("Item %d: %v", i, err)
+		}
+	}
 
+	return n.model.CheckGradients(items, epsilon)
+}
+
+// ComputeLoss returns the average loss of the network over a set of data without training on it
+func (n *NeuralNet) ComputeLoss(data []*TrainingItem) (float64, error) {
+	// Check all the items match network
+	for i := range data {
+		err := n.checkItem(data[i])
 		if err != nil {
-			return err
+			return 0, fmt.Errorf("Data item %d: %v", i, err)
 		}
 	}
 
-	return nil
+	return n.model.ComputeLoss(data)
 }
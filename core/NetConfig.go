@@ -0,0 +1,64 @@
+package core
+
+import "math/rand"
+
+// This file holds the config struct used to build a NeuralNet with CreateNetworkFromConfig
+
+// NetworkConfig holds all the options used to build a network
+type NetworkConfig struct {
+	// LayerSizes is the number of nodes in each layer, starting with the input layer and ending with the output layer
+	LayerSizes []int
+
+	// Activations is the activation used by each layer after the input layer, so it needs len(LayerSizes)-1 entries
+	// Every layer uses Sigmoid if this is left nil
+	Activations []Activation
+
+	// Initializers is the initializer used for the weights of each layer after the input layer
+	// so it needs len(LayerSizes)-1 entries, every layer uses FanInUniform if this is left nil
+	Initializers []Initializer
+
+	// DropoutRates is the fraction of each hidden layer's outputs that are dropped while training, so it needs
+	// len(LayerSizes)-2 entries. A rate of 0 doesn't add dropout to that layer and leaving it nil turns dropout off
+	DropoutRates []float64
+
+	// DropoutSeed seeds the random number generators that pick which outputs are dropped so runs can be reproduced
+	// A seed of 0 seeds them from Rand
+	DropoutSeed int64
+
+	// Rand is the random number generator used to initialize the weights and seed dropout
+	// util.GetRand is used if it is nil, so seeding that with util.SetSeed also makes the network reproducible
+	Rand *rand.Rand
+
+	// BatchNorm adds batch normalization to every hidden layer, between the weights and the activation
+	// Batch normalization learns from the statistics of each batch so the network should be trained with TrainBatch
+	BatchNorm bool
+
+	// LayerNorm adds layer normalization to every hidden layer, between the weights and the activation
+	// It normalizes each sample on its own so it works with Train, it can't be used together with BatchNorm
+	LayerNorm bool
+
+	// Loss is the loss function the network is trained to minimize
+	// If left nil it is CategoricalCrossEntropy when the output layer is Softmax and MeanSquaredError otherwise
+	Loss Loss
+
+	// Optimizer is the update rule used to change the weights while training, plain SGD is used if left nil
+	// Optimizers keep state about the weights they update so every network needs its own optimizer
+	Optimizer Optimizer
+
+	// Regularizer is an optional L1, L2 or elastic net penalty on the weights that is added to the loss while training
+	Regularizer Regularizer
+
+	// WeightDecay is the fraction of each weight that is removed every update, scaled by the learning rate
+	// It is applied directly to the weights after the optimizer, 0 turns it off
+	WeightDecay float64
+
+	// Clipper optionally clips the gradients by value, by the norm of each layer or by the norm of the whole network
+	// before every update, which keeps deep networks from blowing up while training
+	Clipper Clipper
+
+	// LearningRate is the learning rate used when training the network
+	LearningRate float64
+
+	// Scheduler optionally changes the learning rate as the network trains, starting from LearningRate
+	Scheduler Scheduler
+}
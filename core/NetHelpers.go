@@ -2,9 +2,8 @@ package core
 
 import (
 	"errors"
-	"math"
-
-	"gonum.org/v1/gonum/stat/distuv"
+	"strconv"
+	"strings"
 
 	"github.com/shimmy568/GoNeuralNetworks/data"
 
@@ -13,25 +12,140 @@ import (
 
 // This file stores some of the helper functions for the NeuralNetwork struct
 
-// generateWeights generates a random set of weights for the creation of the network
-func generateWeights(sizeX int, sizeY int) []float64 {
+// layersMetadataTag is the first value of the metadata line in model files that store a list of layer sizes
+const layersMetadataTag = "layers"
+
+// activationsHeaderTag is the first value of the header line in model files that stores the activation of each layer
+const activationsHeaderTag = "activations"
+
+// batchNormHeaderTag is the header line in model files of networks that use batch normalization
+const batchNormHeaderTag = "batchnorm"
+
+// layerNormHeaderTag is the header line in model files of networks that use layer normalization
+const layerNormHeaderTag = "layernorm"
+
+// denseToCSVLine converts a matrix to a single line of comma separated values in row major order
+// Values are written with as many digits as are needed to read them back exactly
+func denseToCSVLine(m mat.Matrix) string {
+	r, c := m.Dims()
+	var str strings.Builder
+	for curRow := 0; curRow < r; curRow++ {
+		for curCol := 0; curCol < c; curCol++ {
+			str.WriteString(strconv.FormatFloat(m.At(curRow, curCol), 'g', -1, 64) + ",")
+		}
+	}
+	str.WriteByte('\n')
+
+	return str.String()
+}
+
+// parseCSVLineIntoDense parses a line written by denseToCSVLine into an existing matrix of the same dims
+func parseCSVLineIntoDense(line string, m *mat.Dense) error {
+	values := strings.Split(line, ",")
+	r, c := m.Dims()
+	if len(values) < r*c {
+		return errors.New("Line in model file doesn't have enough values for the layer")
+	}
+
+	for curRow := 0; curRow < r; curRow++ {
+		for curCol := 0; curCol < c; curCol++ {
+			// Parse the float and check for error
+			val, err := strconv.ParseFloat(values[(curRow*c)+curCol], 64)
+			if err != nil {
+				return err
+			}
+
+			m.Set(curRow, curCol, val)
+		}
+	}
+
+	return nil
+}
+
+// layerSizesToMetadataLine creates the metadata line that is written at the top of saved model files
+func layerSizesToMetadataLine(layerSizes []int) string {
+	var str strings.Builder
+	str.WriteString(layersMetadataTag)
+	for i := range layerSizes {
+		str.WriteString("," + strconv.Itoa(layerSizes[i]))
+	}
+	str.WriteByte('\n')
+
+	return str.String()
+}
+
+// parseMetadataLine parses the metadata line of a saved model file into the list of layer sizes
+// Older model files have a metadata line of the form inputCount,outputCount,hiddenLayerCount,hiddenLayerSize
+// and newer ones are of the form layers,inputCount,layerSize...,outputCount
+func parseMetadataLine(line string) ([]int, error) {
+	splitData := strings.Split(line, ",")
+
+	// Handle the old metadata format where all the hidden layers are the same size
+	if splitData[0] != layersMetadataTag {
+		if len(splitData) != 4 {
+			return nil, errors.New("Metadata line of model file is in an invalid format")
+		}
+
+		// Spots in the array are as follows [inputCount, outputCount, hiddenLayerCount, hiddenLayerSize]
+		metadata := make([]int, 4)
+		for i := range splitData {
+			val, err := strconv.Atoi(splitData[i])
+			if err != nil {
+				return nil, err
+			}
+			metadata[i] = val
+		}
 
-	d := distuv.Uniform{
-		Min: -1 / math.Sqrt(float64(sizeY)),
-		Max: 1 / math.Sqrt(float64(sizeY)),
+		layerSizes := []int{metadata[0]}
+		for i := 0; i < metadata[2]; i++ {
+			layerSizes = append(layerSizes, metadata[3])
+		}
+		return append(layerSizes, metadata[1]), nil
 	}
 
-	data := make([]float64, sizeX*sizeY)
-	for i := 0; i < sizeX*sizeY; i++ {
-		data[i] = d.Rand()
+	// Parse the list of layer sizes
+	if len(splitData) < 3 {
+		return nil, errors.New("Metadata line of model file needs at least an input and an output layer")
+	}
+	layerSizes := make([]int, len(splitData)-1)
+	for i := range layerSizes {
+		val, err := strconv.Atoi(splitData[i+1])
+		if err != nil {
+			return nil, err
+		}
+		layerSizes[i] = val
 	}
 
-	return data
+	return layerSizes, nil
 }
 
-// sigmoidWrapper is a function that wraps the sigmoid function to allow for use is mat.Apply
-func sigmoidWrapper(row int, col int, value float64) float64 {
-	return sigmoid(value)
+// activationsToHeaderLine creates the header line that stores the activations of a network in saved model files
+func activationsToHeaderLine(activations []Activation) string {
+	names := make([]string, len(activations))
+	for i := range activations {
+		names[i] = activations[i].Name()
+	}
+
+	return activationsHeaderTag + "," + strings.Join(names, ",") + "\n"
+}
+
+// readModelHeader reads the tagged header lines that come after the metadata line of a saved model file
+// It returns the values of each header line keyed by their tag and the index of the first line of weights
+func readModelHeader(lines []string) (map[string][]string, int) {
+	header := make(map[string][]string)
+
+	i := 1
+	for ; i < len(lines); i++ {
+		// Lines of weights start with a number and header lines start with their tag
+		splitData := strings.Split(lines[i], ",")
+		if _, err := strconv.ParseFloat(splitData[0], 64); err == nil || lines[i] == "" {
+			break
+		}
+
+		header[splitData[0]] = splitData[1:]
+	}
+
+	return header, i
 }
 
 // vectorizeMatrix takes a 2D matrix with many columns and turns it into a matrix with only 1 row (vector)
@@ -51,6 +165,18 @@ func vectorizeMatrix(matrix *mat.Dense) *mat.VecDense {
 	return output
 }
 
+// CreateMonochromeImageTrainingItem creates a training item out of an image laid out the same way
+// as the images given to TrainMonnochromeImage and PredictMonochromeImage
+func CreateMonochromeImageTrainingItem(image *data.MonochromeImageData, expectedOutput *mat.VecDense) *TrainingItem {
+	return CreateTrainingItem(vectorizeMatrix(image.GetDense()), expectedOutput)
+}
+
+// CreateImageTrainingItem creates a training item out of an image with its pixels laid out one row after another,
+// which is the layout image layers like Conv2D take for an input shape of GetMonochromeImageShape(image)
+func CreateImageTrainingItem(image *data.MonochromeImageData, expectedOutput *mat.VecDense) *TrainingItem {
+	return CreateTrainingItem(image.GetVector(), expectedOutput)
+}
+
 // TrainMonnochromeImage trains a neural network
 func (n *NeuralNet) TrainMonnochromeImage(image *data.MonochromeImageData, expectedOutput *mat.VecDense) (err error) {
 	// Check that the image is the right size
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// TestLoadOldWeights checks that a model saved before biases and activations were stored in the file
+// loads with zero biases and predicts what the old network did, sigmoid of the weights times the input at every layer
+func TestLoadOldWeights(t *testing.T) {
+	n := CreateNetwork(2, 1, 1, 2, 0.1)
+	for _, d := range n.denseLayers {
+		d.GetBiases().Apply(func(i, j int, v float64) float64 { return 1 }, d.GetBiases())
+	}
+
+	err := n.LoadWeights("testdata/old_weights.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weights := []*mat.Dense{
+		mat.NewDense(2, 2, []float64{0.5, -0.25, 0.75, 1}),
+		mat.NewDense(1, 2, []float64{-1.5, 2}),
+	}
+	for i, d := range n.denseLayers {
+		if !mat.Equal(d.GetWeights(), weights[i]) {
+			t.Errorf("Weights of layer %d = %v, want %v", i, mat.Formatted(d.GetWeights()), mat.Formatted(weights[i]))
+		}
+		if mat.Max(d.GetBiases()) != 0 || mat.Min(d.GetBiases()) != 0 {
+			t.Errorf("Biases of layer %d = %v, want zero", i, mat.Formatted(d.GetBiases()))
+		}
+	}
+
+	// Hidden layer: 0.5*1 - 0.25*0.5 = 0.375 and 0.75*1 + 1*0.5 = 1.25
+	want := sigmoid(-1.5*sigmoid(0.375) + 2*sigmoid(1.25))
+	got := n.Predict([]float64{1, 0.5}).AtVec(0)
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("Predict = %f, want %f", got, want)
+	}
+}
+
+// TestSaveWeightsError checks that SaveWeights returns the error when the file can't be created
+func TestSaveWeightsError(t *testing.T) {
+	n := CreateNetwork(2, 1, 1, 2, 0.1)
+	err := n.SaveWeights(filepath.Join(t.TempDir(), "missing", "weights.csv"))
+	if err == nil {
+		t.Error("SaveWeights didn't return an error")
+	}
+}
@@ -0,0 +1,263 @@
+package core
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the optimizers that are used to update the parameters of a network while training
+
+// Param is a learnable parameter of a network along with the gradient found for it during backpropagation
+type Param struct {
+	// Value is the current value of the parameter
+	Value *mat.Dense
+
+	// Grad is the gradient of the loss with respect to Value from the last backpropagation step
+	Grad *mat.Dense
+
+	// Regularize is true for parameters that weight decay should be applied to, which is weights but not biases
+	Regularize bool
+}
+
+// createParam creates a param for a value with a zeroed gradient of the same size
+func createParam(value *mat.Dense, regularize bool) *Param {
+	r, c := value.Dims()
+	return &Param{
+		Value:      value,
+		Grad:       mat.NewDense(r, c, nil),
+		Regularize: regularize,
+	}
+}
+
+// shareValue returns a param with the same value but its own zeroed gradient, it is used to give each worker its own gradients
+func (p *Param) shareValue() *Param {
+	return createParam(p.Value, p.Regularize)
+}
+
+// Optimizer is an update rule that changes the parameters of a network using their gradients
+// Optimizers keep state for each parameter so every network needs its own optimizer
+type Optimizer interface {
+	// Update changes the value of a parameter using its gradient and the learning rate
+	Update(param *Param, learningRate float64)
+}
+
+// paramState is the state an optimizer keeps for a single parameter
+type paramState struct {
+	// step is the number of updates that have been made to the parameter
+	step int
+
+	// first and second are running estimates of the gradient, what they hold depends on the optimizer
+	first  *mat.Dense
+	second *mat.Dense
+}
+
+// optimizerState keeps track of the state of every parameter an optimizer has updated
+type optimizerState struct {
+	states map[*Param]*paramState
+}
+
+// getState returns the state for a parameter, creating zeroed state the first time the parameter is seen
+func (o *optimizerState) getState(param *Param) *paramState {
+	if o.states == nil {
+		o.states = make(map[*Param]*paramState)
+	}
+
+	state, ok := o.states[param]
+	if !ok {
+		r, c := param.Value.Dims()
+		state = &paramState{
+			first:  mat.NewDense(r, c, nil),
+			second: mat.NewDense(r, c, nil),
+		}
+		o.states[param] = state
+	}
+
+	return state
+}
+
+// SGD is plain stochastic gradient descent, value -= learningRate * gradient
+type SGD struct{}
+
+// CreateSGD creates a plain gradient descent optimizer
+func CreateSGD() *SGD {
+	return &SGD{}
+}
+
+// Update moves the parameter against its gradient
+func (o *SGD) Update(param *Param, learningRate float64) {
+	param.Value.Apply(func(i, j int, v float64) float64 {
+		return v - learningRate*param.Grad.At(i, j)
+	}, param.Value)
+}
+
+// Momentum is gradient descent that keeps a velocity for each parameter so updates keep moving in consistent directions
+type Momentum struct {
+	optimizerState
+
+	// Momentum is how much of the previous velocity is kept each step, usually 0.9
+	Momentum float64
+
+	// Nesterov makes the update look ahead along the velocity before applying the gradient
+	Nesterov bool
+}
+
+// CreateMomentum creates a gradient descent optimizer with classic momentum
+func CreateMomentum(momentum float64) *Momentum {
+	return &Momentum{Momentum: momentum}
+}
+
+// CreateNesterov creates a gradient descent optimizer with nesterov momentum
+func CreateNesterov(momentum float64) *Momentum {
+	return &Momentum{Momentum: momentum, Nesterov: true}
+}
+
+// Update updates the velocity of the parameter and moves the parameter along it
+func (o *Momentum) Update(param *Param, learningRate float64) {
+	state := o.getState(param)
+	velocity := state.first
+
+	// velocity = momentum * velocity - learningRate * gradient
+	velocity.Apply(func(i, j int, v float64) float64 {
+		return o.Momentum*v - learningRate*param.Grad.At(i, j)
+	}, velocity)
+
+	param.Value.Apply(func(i, j int, v float64) float64 {
+		if o.Nesterov {
+			return v + o.Momentum*velocity.At(i, j) - learningRate*param.Grad.At(i, j)
+		}
+		return v + velocity.At(i, j)
+	}, param.Value)
+}
+
+// Adagrad scales the learning rate of every value by the inverse root of the sum of all its squared gradients
+type Adagrad struct {
+	optimizerState
+
+	// Epsilon keeps the update from dividing by zero
+	Epsilon float64
+}
+
+// CreateAdagrad creates an adagrad optimizer
+func CreateAdagrad() *Adagrad {
+	return &Adagrad{Epsilon: 1e-8}
+}
+
+// Update adds the squared gradient to the running sum and moves the parameter
+func (o *Adagrad) Update(param *Param, learningRate float64) {
+	sum := o.getState(param).second
+
+	sum.Apply(func(i, j int, v float64) float64 {
+		g := param.Grad.At(i, j)
+		return v + g*g
+	}, sum)
+
+	param.Value.Apply(func(i, j int, v float64) float64 {
+		return v - learningRate*param.Grad.At(i, j)/(math.Sqrt(sum.At(i, j))+o.Epsilon)
+	}, param.Value)
+}
+
+// RMSProp scales the learning rate of every value by the inverse root of a moving average of its squared gradients
+type RMSProp struct {
+	optimizerState
+
+	// Decay is how much of the moving average is kept each step, usually 0.9
+	Decay float64
+
+	// Epsilon keeps the update from dividing by zero
+	Epsilon float64
+}
+
+// CreateRMSProp creates an rmsprop optimizer
+func CreateRMSProp() *RMSProp {
+	return &RMSProp{Decay: 0.9, Epsilon: 1e-8}
+}
+
+// Update updates the moving average of the squared gradient and moves the parameter
+func (o *RMSProp) Update(param *Param, learningRate float64) {
+	average := o.getState(param).second
+
+	average.Apply(func(i, j int, v float64) float64 {
+		g := param.Grad.At(i, j)
+		return o.Decay*v + (1-o.Decay)*g*g
+	}, average)
+
+	param.Value.Apply(func(i, j int, v float64) float64 {
+		return v - learningRate*param.Grad.At(i, j)/(math.Sqrt(average.At(i, j))+o.Epsilon)
+	}, param.Value)
+}
+
+// Adam keeps moving averages of the gradient and the squared gradient and uses their bias corrected ratio as the step
+type Adam struct {
+	optimizerState
+
+	// Beta1 and Beta2 are how much of the moving averages of the gradient and squared gradient are kept each step
+	Beta1 float64
+	Beta2 float64
+
+	// Epsilon keeps the update from dividing by zero
+	Epsilon float64
+}
+
+// CreateAdam creates an adam optimizer with the usual settings
+func CreateAdam() *Adam {
+	return &Adam{Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8}
+}
+
+// Update updates the moment estimates and moves the parameter
+func (o *Adam) Update(param *Param, learningRate float64) {
+	adamUpdate(o.getState(param), param, learningRate, o.Beta1, o.Beta2, o.Epsilon, 0)
+}
+
+// AdamW is adam with weight decay that is applied directly to the weights instead of being added to the gradient
+type AdamW struct {
+	optimizerState
+
+	// Beta1 and Beta2 are how much of the moving averages of the gradient and squared gradient are kept each step
+	Beta1 float64
+	Beta2 float64
+
+	// Epsilon keeps the update from dividing by zero
+	Epsilon float64
+
+	// WeightDecay is the fraction of each weight that is removed every step, scaled by the learning rate
+	WeightDecay float64
+}
+
+// CreateAdamW creates an adamw optimizer with the usual settings and the given weight decay
+func CreateAdamW(weightDecay float64) *AdamW {
+	return &AdamW{Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8, WeightDecay: weightDecay}
+}
+
+// Update updates the moment estimates, decays the weights and moves the parameter
+func (o *AdamW) Update(param *Param, learningRate float64) {
+	weightDecay := 0.0
+	if param.Regularize {
+		weightDecay = o.WeightDecay
+	}
+
+	adamUpdate(o.getState(param), param, learningRate, o.Beta1, o.Beta2, o.Epsilon, weightDecay)
+}
+
+// adamUpdate does the update shared by adam and adamw
+func adamUpdate(state *paramState, param *Param, learningRate, beta1, beta2, epsilon, weightDecay float64) {
+	state.step++
+	firstCorrection := 1 - math.Pow(beta1, float64(state.step))
+	secondCorrection := 1 - math.Pow(beta2, float64(state.step))
+
+	// Update the moving averages of the gradient and the squared gradient
+	state.first.Apply(func(i, j int, v float64) float64 {
+		return beta1*v + (1-beta1)*param.Grad.At(i, j)
+	}, state.first)
+	state.second.Apply(func(i, j int, v float64) float64 {
+		g := param.Grad.At(i, j)
+		return beta2*v + (1-beta2)*g*g
+	}, state.second)
+
+	// Move the parameter using the bias corrected averages
+	param.Value.Apply(func(i, j int, v float64) float64 {
+		m := state.first.At(i, j) / firstCorrection
+		s := state.second.At(i, j) / secondCorrection
+		return v - learningRate*(m/(math.Sqrt(s)+epsilon)+weightDecay*v)
+	}, param.Value)
+}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the parallel trainer that splits each batch across goroutines so training can run on more than one core
+
+// ParallelTrainer trains a network by splitting each batch into one part per worker and running the parts at the same time
+// Each worker has its own copy of the layers that shares the params of the network but keeps its own gradients
+//
+// By default the gradients of the workers are added together in worker order and the network is updated once per batch,
+// so training gives the same result every run for the same number of workers. In Hogwild mode every worker updates
+// the shared params with plain gradient descent as soon as its part is done without any locking, with the learning rate
+// scaled by its share of the batch so a batch moves the params about as far as it does without Hogwild.
+// The workers don't wait for each other but race on the params by design, so it isn't deterministic
+// and will be reported by the race detector. It only works with the SGD optimizer
+//
+// With a single core splitting a batch is slower than training on one goroutine and it hasn't been measured
+// with more cores yet, BenchmarkFitWorkers measures it on the current machine
+type ParallelTrainer struct {
+	model   *Sequential
+	workers []*Sequential
+
+	// grads holds the sum of the gradients of the workers for each param of the network, it is reused every step
+	grads []*mat.Dense
+
+	// Hogwild makes every worker update the params on its own without waiting for the others
+	// The network has to use the SGD optimizer, TrainBatch returns an error otherwise
+	Hogwild bool
+}
+
+// CreateParallelTrainer creates a trainer for the model that splits each batch across workerCount goroutines
+// Every layer of the model needs to support being trained in parallel, which all the layers in this package do
+// Batch normalization layers compute their statistics over the part of the batch each worker gets
+func CreateParallelTrainer(model *Sequential, workerCount int) (*ParallelTrainer, error) {
+	if workerCount < 1 {
+		return nil, errors.New("A parallel trainer needs at least one worker")
+	}
+
+	p := &ParallelTrainer{model: model}
+	for w := 0; w < workerCount; w++ {
+		layers := make([]Layer, len(model.layers))
+		for i := range model.layers {
+			l, ok := model.layers[i].(workerLayer)
+			if !ok {
+				return nil, fmt.Errorf("Layer %d (%T) can't be trained in parallel", i, model.layers[i])
+			}
+			layers[i] = l.createWorker()
+		}
+
+		// Hogwild workers update the params themselves with plain gradient descent, see checkHogwild
+		worker := CreateSequential(layers...)
+		worker.Optimizer = CreateSGD()
+		p.workers = append(p.workers, worker)
+	}
+
+	return p, nil
+}
+
+// GetWorkerCount returns the number of workers each batch is split across
+func (p *ParallelTrainer) GetWorkerCount() int {
+	return len(p.workers)
+}
+
+// TrainBatch trains the network on a set of training data in mini-batches of batchSize items
+// with each batch split across the workers
+func (p *ParallelTrainer) TrainBatch(trainingData []*TrainingItem, batchSize int) error {
+	if batchSize <= 0 {
+		return errors.New("Batch size must be at least 1")
+	}
+
+	err := p.checkHogwild()
+	if err != nil {
+		return err
+	}
+
+	// Check all the items are the same size before any training is done
+	err = checkTrainingItems(trainingData)
+	if err != nil {
+		return err
+	}
+
+	p.model.trainBatches(trainingData, batchSize, p.trainMatrix)
+
+	return nil
+}
+
+// checkHogwild returns an error if Hogwild is on and the network uses an optimizer other than SGD
+// Other optimizers keep state for each param that every worker would need to update at once, so they can't be used
+func (p *ParallelTrainer) checkHogwild() error {
+	if !p.Hogwild {
+		return nil
+	}
+	if _, ok := p.model.getOptimizer().(*SGD); !ok {
+		return fmt.Errorf("Hogwild training only works with the SGD optimizer, the network uses %T", p.model.getOptimizer())
+	}
+	return nil
+}
+
+// trainMatrix does one step of training on a batch of samples with one sample per column and returns the loss
+func (p *ParallelTrainer) trainMatrix(input *mat.Dense, targets *mat.Dense) float64 {
+	r, c := input.Dims()
+	targetRows, _ := targets.Dims()
+
+	// Give each worker an equal part of the batch, the first workers get one more sample if it doesn't divide evenly
+	starts := make([]int, len(p.workers)+1)
+	for w := range p.workers {
+		size := c / len(p.workers)
+		if w < c%len(p.workers) {
+			size++
+		}
+		starts[w+1] = starts[w] + size
+	}
+
+	// The gradients and losses of each worker are averages over its part, so weight them by the size of the part
+	weights := make([]float64, len(p.workers))
+	for w := range p.workers {
+		weights[w] = float64(starts[w+1]-starts[w]) / float64(c)
+	}
+
+	// Copy the settings of the network into the workers, Hogwild workers each take a step for their share of the batch
+	loss := p.model.GetLoss()
+	learningRate := p.model.GetLearningRate()
+	for w, worker := range p.workers {
+		worker.Loss = loss
+		worker.Regularizer = p.model.Regularizer
+		worker.WeightDecay = p.model.WeightDecay
+		worker.Clipper = p.model.Clipper
+		worker.LearningRate = learningRate * weights[w]
+		worker.clipCount = 0
+	}
+
+	// Run every worker on its part of the batch
+	losses := make([]float64, len(p.workers))
+	var wg sync.WaitGroup
+	for w := range p.workers {
+		if starts[w] == starts[w+1] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			workerInput := input.Slice(0, r, starts[w], starts[w+1]).(*mat.Dense)
+			workerTargets := targets.Slice(0, targetRows, starts[w], starts[w+1]).(*mat.Dense)
+
+			if p.Hogwild {
+				losses[w] = p.workers[w].trainMatrix(workerInput, workerTargets)
+			} else {
+				losses[w] = p.workers[w].computeGradients(workerInput, workerTargets)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	lossValue := 0.0
+	for w := range p.workers {
+		lossValue += losses[w] * weights[w]
+	}
+
+	if p.Hogwild {
+		// The workers already updated the params so only the bookkeeping of the network is left
+		for _, worker := range p.workers {
+			if worker.clipCount > 0 {
+				p.model.clipCount++
+				break
+			}
+		}
+		p.model.progress.Step++
+		p.model.paramsUpdated()
+	} else {
+		// Add the gradients of the workers together in order and update the network once
+		p.reduceGradients(weights)
+		p.model.applyGradients()
+	}
+
+	// Let the layers of the workers know the shared params changed
+	for _, worker := range p.workers {
+		worker.paramsUpdated()
+	}
+	p.syncState(weights)
+
+	return lossValue
+}
+
+// reduceGradients sets the gradient of every param of the network to the weighted sum of the gradients of the workers
+// The params are split between goroutines, but each one adds up the workers in order so the result is the same every run
+func (p *ParallelTrainer) reduceGradients(weights []float64) {
+	params := p.model.Params()
+	workerParams := make([][]*Param, len(p.workers))
+	for w := range p.workers {
+		workerParams[w] = p.workers[w].Params()
+	}
+
+	if p.grads == nil {
+		p.grads = make([]*mat.Dense, len(params))
+		for i := range params {
+			rows, cols := params[i].Value.Dims()
+			p.grads[i] = mat.NewDense(rows, cols, nil)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < len(p.workers); g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(params); i += len(p.workers) {
+				p.grads[i].Zero()
+				for w := range p.workers {
+					if weights[w] > 0 {
+						addScaled(p.grads[i], weights[w], workerParams[w][i].Grad)
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for i := range params {
+		params[i].Grad = p.grads[i]
+	}
+}
+
+// syncState sets the state of the network, such as the running statistics of batch normalization,
+// to the weighted average of the state of the workers and then copies it back to every worker
+func (p *ParallelTrainer) syncState(weights []float64) {
+	state := p.model.state()
+	if len(state) == 0 {
+		return
+	}
+
+	workerState := make([][]*mat.Dense, len(p.workers))
+	for w := range p.workers {
+		workerState[w] = p.workers[w].state()
+	}
+
+	for i := range state {
+		state[i].Zero()
+		for w := range p.workers {
+			if weights[w] > 0 {
+				addScaled(state[i], weights[w], workerState[w][i])
+			}
+		}
+		for w := range p.workers {
+			workerState[w][i].Copy(state[i])
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// BenchmarkFitWorkers times one epoch of Fit on MNIST sized data with different numbers of workers
+// The speedup over 1 worker depends on the number of cores of the machine, run it with -cpu to compare
+func BenchmarkFitWorkers(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	items := make([]*TrainingItem, 2048)
+	for i := range items {
+		inputs := mat.NewVecDense(784, nil)
+		for o := 0; o < 784; o++ {
+			inputs.SetVec(o, r.Float64())
+		}
+		targets := mat.NewVecDense(10, nil)
+		targets.SetVec(r.Intn(10), 1)
+		items[i] = CreateTrainingItem(inputs, targets)
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Workers%d", workers), func(b *testing.B) {
+			n, err := CreateNetworkFromConfig(NetworkConfig{
+				LayerSizes:   []int{784, 128, 10},
+				Activations:  []Activation{ReLU{}, Softmax{}},
+				LearningRate: 0.01,
+				Rand:         rand.New(rand.NewSource(1)),
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := n.Fit(items, FitConfig{Epochs: 1, BatchSize: 64, Workers: workers})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// createParallelTestModel creates a small network with weights from the given seed
+func createParallelTestModel(seed int64) *Sequential {
+	r := rand.New(rand.NewSource(seed))
+	model := CreateSequential(
+		CreateDenseWithRand(4, 6, FanInUniform{}, r),
+		CreateActivationLayer(Tanh{}),
+		CreateDenseWithRand(6, 3, FanInUniform{}, r),
+		CreateActivationLayer(Softmax{}),
+	)
+	model.LearningRate = 0.1
+	return model
+}
+
+// createParallelTestItems creates items with random inputs and one hot targets from the given seed
+func createParallelTestItems(seed int64, count int) []*TrainingItem {
+	r := rand.New(rand.NewSource(seed))
+	items := make([]*TrainingItem, count)
+	for i := range items {
+		inputs := mat.NewVecDense(4, nil)
+		for o := 0; o < 4; o++ {
+			inputs.SetVec(o, r.NormFloat64())
+		}
+		targets := mat.NewVecDense(3, nil)
+		targets.SetVec(r.Intn(3), 1)
+		items[i] = CreateTrainingItem(inputs, targets)
+	}
+	return items
+}
+
+// trainParallel trains a new test model on the items with the given number of workers and returns it
+func trainParallel(t *testing.T, workers int, items []*TrainingItem, batchSize int) *Sequential {
+	model := createParallelTestModel(1)
+	trainer, err := CreateParallelTrainer(model, workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for epoch := 0; epoch < 3; epoch++ {
+		err = trainer.TrainBatch(items, batchSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return model
+}
+
+// maxParamDifference returns the largest difference between the params of two models with the same layers
+func maxParamDifference(a *Sequential, b *Sequential) float64 {
+	difference := 0.0
+	aParams, bParams := a.Params(), b.Params()
+	for i := range aParams {
+		rows, cols := aParams[i].Value.Dims()
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				difference = math.Max(difference, math.Abs(aParams[i].Value.At(r, c)-bParams[i].Value.At(r, c)))
+			}
+		}
+	}
+	return difference
+}
+
+// TestParallelMatchesSingleWorker checks that splitting batches across workers gives the same weights as one worker
+// up to the order the floating point sums are done in
+func TestParallelMatchesSingleWorker(t *testing.T) {
+	items := createParallelTestItems(2, 50)
+
+	single := createParallelTestModel(1)
+	for epoch := 0; epoch < 3; epoch++ {
+		err := single.TrainBatch(items, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, workers := range []int{1, 2, 3, 8} {
+		parallel := trainParallel(t, workers, items, 8)
+		if difference := maxParamDifference(single, parallel); difference > 1e-12 {
+			t.Errorf("%d workers: params differ from a single worker by %e", workers, difference)
+		}
+	}
+}
+
+// TestParallelIsDeterministic checks that two runs with the same weights, items and number of workers give the same weights
+func TestParallelIsDeterministic(t *testing.T) {
+	items := createParallelTestItems(2, 50)
+	first := trainParallel(t, 4, items, 10)
+	second := trainParallel(t, 4, items, 10)
+	if difference := maxParamDifference(first, second); difference != 0 {
+		t.Errorf("Params of two runs differ by %e", difference)
+	}
+}
+
+// TestParallelSmallBatch checks that batches with fewer items than workers leave the extra workers out
+func TestParallelSmallBatch(t *testing.T) {
+	items := createParallelTestItems(3, 7)
+
+	single := createParallelTestModel(1)
+	for epoch := 0; epoch < 3; epoch++ {
+		err := single.TrainBatch(items, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parallel := trainParallel(t, 5, items, 3)
+	if difference := maxParamDifference(single, parallel); difference > 1e-12 {
+		t.Errorf("Params differ from a single worker by %e", difference)
+	}
+	for _, param := range parallel.Params() {
+		rows, cols := param.Value.Dims()
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				if math.IsNaN(param.Value.At(r, c)) {
+					t.Fatal("Params are NaN after training with empty workers")
+				}
+			}
+		}
+	}
+}
+
+// TestHogwildNeedsSGD checks that Hogwild training refuses optimizers that keep state for each param
+func TestHogwildNeedsSGD(t *testing.T) {
+	items := createParallelTestItems(2, 10)
+
+	model := createParallelTestModel(1)
+	model.Optimizer = CreateAdam()
+	trainer, err := CreateParallelTrainer(model, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainer.Hogwild = true
+	if err := trainer.TrainBatch(items, 4); err == nil {
+		t.Error("TrainBatch didn't return an error for Hogwild with Adam")
+	}
+	if _, err := model.Fit(items, FitConfig{Epochs: 1, BatchSize: 4, Workers: 2, Hogwild: true}); err == nil {
+		t.Error("Fit didn't return an error for Hogwild with Adam")
+	}
+
+	model.Optimizer = nil
+	if err := trainer.TrainBatch(items, 4); err != nil {
+		t.Errorf("TrainBatch returned an error for Hogwild with SGD: %v", err)
+	}
+}
+
+// TestHogwildSingleWorker checks that a single Hogwild worker takes the same steps as training without a trainer,
+// since the learning rate of each worker is scaled by its share of the batch
+func TestHogwildSingleWorker(t *testing.T) {
+	items := createParallelTestItems(2, 20)
+
+	single := createParallelTestModel(1)
+	err := single.TrainBatch(items, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hogwild := createParallelTestModel(1)
+	trainer, err := CreateParallelTrainer(hogwild, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainer.Hogwild = true
+	err = trainer.TrainBatch(items, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if difference := maxParamDifference(single, hogwild); difference > 1e-12 {
+		t.Errorf("Params differ from training without a trainer by %e", difference)
+	}
+}
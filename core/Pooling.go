@@ -0,0 +1,310 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the pooling layers that shrink the images coming out of convolutional layers
+
+// pool2D holds the shape and windows shared by the 2D pooling layers
+// Each channel is pooled on its own so the output has the same number of channels as the input
+type pool2D struct {
+	inputShape  ImageShape
+	outputShape ImageShape
+	poolSize    int
+	stride      int
+}
+
+// createPool2D checks the size of the pool and finds the shape of its output
+// A stride of 0 uses the pool size so the windows don't overlap
+func createPool2D(inputShape ImageShape, poolSize int, stride int) (pool2D, error) {
+	if err := inputShape.check(); err != nil {
+		return pool2D{}, err
+	}
+	if poolSize <= 0 {
+		return pool2D{}, errors.New("Pool size must be at least 1")
+	}
+	if stride < 0 {
+		return pool2D{}, errors.New("Stride can't be negative")
+	}
+	if stride == 0 {
+		stride = poolSize
+	}
+	if inputShape.Height < poolSize || inputShape.Width < poolSize {
+		return pool2D{}, fmt.Errorf("Pool size %d is larger than the %s input", poolSize, inputShape)
+	}
+
+	return pool2D{
+		inputShape: inputShape,
+		outputShape: ImageShape{
+			Channels: inputShape.Channels,
+			Height:   (inputShape.Height-poolSize)/stride + 1,
+			Width:    (inputShape.Width-poolSize)/stride + 1,
+		},
+		poolSize: poolSize,
+		stride:   stride,
+	}, nil
+}
+
+// GetInputShape returns the shape of the images the layer takes
+func (p *pool2D) GetInputShape() ImageShape {
+	return p.inputShape
+}
+
+// GetOutputShape returns the shape of the images the layer returns, its size is the input count of the next layer
+func (p *pool2D) GetOutputShape() ImageShape {
+	return p.outputShape
+}
+
+// GetPoolSize returns the width and height of the window each output is pooled from
+func (p *pool2D) GetPoolSize() int {
+	return p.poolSize
+}
+
+// GetStride returns how far the window moves between outputs
+func (p *pool2D) GetStride() int {
+	return p.stride
+}
+
+// checkInput panics if a batch doesn't have one row per value of the input shape
+func (p *pool2D) checkInput(layer string, input *mat.Dense) {
+	rows, _ := input.Dims()
+	if rows != p.inputShape.Size() {
+		panic(fmt.Sprintf("%s expects %d inputs for %s images, got %d", layer, p.inputShape.Size(), p.inputShape, rows))
+	}
+}
+
+// window returns the rows of the input that the output at the given row is pooled from
+func (p *pool2D) window(outputRow int) []int {
+	out := p.outputShape
+	c := outputRow / (out.Height * out.Width)
+	oy := outputRow / out.Width % out.Height
+	ox := outputRow % out.Width
+
+	rows := make([]int, 0, p.poolSize*p.poolSize)
+	for y := oy * p.stride; y < oy*p.stride+p.poolSize; y++ {
+		for x := ox * p.stride; x < ox*p.stride+p.poolSize; x++ {
+			rows = append(rows, p.inputShape.index(c, y, x))
+		}
+	}
+	return rows
+}
+
+// windows returns the window of every output row, it only depends on the shape so layers find it once
+func (p *pool2D) windows() [][]int {
+	output := make([][]int, p.outputShape.Size())
+	for i := range output {
+		output[i] = p.window(i)
+	}
+	return output
+}
+
+// MaxPool2D is a layer that keeps the largest value in each window of each channel
+type MaxPool2D struct {
+	pool2D
+
+	// rows holds the rows of the input that each window is made out of
+	rows [][]int
+
+	// argMax holds the row of the input that each output came from in the last training call to Forward,
+	// one slice per sample, so the gradient can be sent back to only that input
+	argMax [][]int
+}
+
+// CreateMaxPool2D creates a max pooling layer with square windows of the given size
+// A stride of 0 moves the window by its own size so the windows don't overlap
+func CreateMaxPool2D(inputShape ImageShape, poolSize int, stride int) (*MaxPool2D, error) {
+	pool, err := createPool2D(inputShape, poolSize, stride)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MaxPool2D{pool2D: pool, rows: pool.windows()}, nil
+}
+
+// Forward keeps the largest value in every window of each image in the batch, the first one wins ties
+func (l *MaxPool2D) Forward(input *mat.Dense, training bool) *mat.Dense {
+	l.checkInput("MaxPool2D", input)
+	_, n := input.Dims()
+
+	if training {
+		l.argMax = make([][]int, n)
+	}
+
+	output := mat.NewDense(l.outputShape.Size(), n, nil)
+	for j := 0; j < n; j++ {
+		argMax := make([]int, len(l.rows))
+		for i, window := range l.rows {
+			argMax[i] = window[0]
+			for _, row := range window[1:] {
+				if input.At(row, j) > input.At(argMax[i], j) {
+					argMax[i] = row
+				}
+			}
+			output.Set(i, j, input.At(argMax[i], j))
+		}
+
+		if training {
+			l.argMax[j] = argMax
+		}
+	}
+
+	return output
+}
+
+// Backward sends the gradient of each output back to the input it came from, every other input gets no gradient
+// Windows can overlap when the stride is smaller than the pool size so an input can get the gradient of more than one output
+func (l *MaxPool2D) Backward(grad *mat.Dense) *mat.Dense {
+	_, n := grad.Dims()
+	output := mat.NewDense(l.inputShape.Size(), n, nil)
+	for j := 0; j < n; j++ {
+		for i, row := range l.argMax[j] {
+			output.Set(row, j, output.At(row, j)+grad.At(i, j))
+		}
+	}
+
+	return output
+}
+
+// Params returns nil since pooling doesn't learn anything
+func (l *MaxPool2D) Params() []*Param {
+	return nil
+}
+
+// createWorker returns a copy of the layer with its own argmax
+func (l *MaxPool2D) createWorker() Layer {
+	return &MaxPool2D{pool2D: l.pool2D, rows: l.rows}
+}
+
+// AvgPool2D is a layer that averages the values in each window of each channel
+type AvgPool2D struct {
+	pool2D
+
+	// rows holds the rows of the input that each window is made out of
+	rows [][]int
+}
+
+// CreateAvgPool2D creates an average pooling layer with square windows of the given size
+// A stride of 0 moves the window by its own size so the windows don't overlap
+func CreateAvgPool2D(inputShape ImageShape, poolSize int, stride int) (*AvgPool2D, error) {
+	pool, err := createPool2D(inputShape, poolSize, stride)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AvgPool2D{pool2D: pool, rows: pool.windows()}, nil
+}
+
+// Forward averages every window of each image in the batch
+func (l *AvgPool2D) Forward(input *mat.Dense, training bool) *mat.Dense {
+	l.checkInput("AvgPool2D", input)
+	_, n := input.Dims()
+
+	output := mat.NewDense(l.outputShape.Size(), n, nil)
+	for j := 0; j < n; j++ {
+		for i, window := range l.rows {
+			sum := 0.0
+			for _, row := range window {
+				sum += input.At(row, j)
+			}
+			output.Set(i, j, sum/float64(len(window)))
+		}
+	}
+
+	return output
+}
+
+// Backward splits the gradient of each output evenly between the inputs in its window
+func (l *AvgPool2D) Backward(grad *mat.Dense) *mat.Dense {
+	_, n := grad.Dims()
+	output := mat.NewDense(l.inputShape.Size(), n, nil)
+	for j := 0; j < n; j++ {
+		for i, window := range l.rows {
+			share := grad.At(i, j) / float64(len(window))
+			for _, row := range window {
+				output.Set(row, j, output.At(row, j)+share)
+			}
+		}
+	}
+
+	return output
+}
+
+// Params returns nil since pooling doesn't learn anything
+func (l *AvgPool2D) Params() []*Param {
+	return nil
+}
+
+// createWorker returns the layer itself since it doesn't keep anything between Forward and Backward
+func (l *AvgPool2D) createWorker() Layer {
+	return l
+}
+
+// GlobalAvgPool2D is a layer that averages each channel of its input down to a single value
+// It is usually used in place of flattening the last convolutional layer before the dense layers
+type GlobalAvgPool2D struct {
+	inputShape ImageShape
+}
+
+// CreateGlobalAvgPool2D creates a global average pooling layer for the given input shape
+func CreateGlobalAvgPool2D(inputShape ImageShape) (*GlobalAvgPool2D, error) {
+	if err := inputShape.check(); err != nil {
+		return nil, err
+	}
+
+	return &GlobalAvgPool2D{inputShape: inputShape}, nil
+}
+
+// GetInputShape returns the shape of the images the layer takes
+func (l *GlobalAvgPool2D) GetInputShape() ImageShape {
+	return l.inputShape
+}
+
+// GetOutputShape returns the shape of the output, which has one value per channel
+func (l *GlobalAvgPool2D) GetOutputShape() ImageShape {
+	return ImageShape{Channels: l.inputShape.Channels, Height: 1, Width: 1}
+}
+
+// Forward averages every channel of each image in the batch
+func (l *GlobalAvgPool2D) Forward(input *mat.Dense, training bool) *mat.Dense {
+	rows, n := input.Dims()
+	if rows != l.inputShape.Size() {
+		panic(fmt.Sprintf("GlobalAvgPool2D expects %d inputs for %s images, got %d", l.inputShape.Size(), l.inputShape, rows))
+	}
+
+	channelSize := l.inputShape.Height * l.inputShape.Width
+	output := mat.NewDense(l.inputShape.Channels, n, nil)
+	for j := 0; j < n; j++ {
+		for c := 0; c < l.inputShape.Channels; c++ {
+			sum := 0.0
+			for i := c * channelSize; i < (c+1)*channelSize; i++ {
+				sum += input.At(i, j)
+			}
+			output.Set(c, j, sum/float64(channelSize))
+		}
+	}
+
+	return output
+}
+
+// Backward splits the gradient of each channel evenly between every value of the channel
+func (l *GlobalAvgPool2D) Backward(grad *mat.Dense) *mat.Dense {
+	_, n := grad.Dims()
+	channelSize := l.inputShape.Height * l.inputShape.Width
+	return apply(func(i, j int, v float64) float64 {
+		return grad.At(i/channelSize, j) / float64(channelSize)
+	}, mat.NewDense(l.inputShape.Size(), n, nil))
+}
+
+// Params returns nil since pooling doesn't learn anything
+func (l *GlobalAvgPool2D) Params() []*Param {
+	return nil
+}
+
+// createWorker returns the layer itself since it doesn't keep anything between Forward and Backward
+func (l *GlobalAvgPool2D) createWorker() Layer {
+	return l
+}
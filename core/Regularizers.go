@@ -0,0 +1,108 @@
+package core
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the regularizers that penalize large weights to keep a network from overfitting
+
+// Regularizer is a penalty on the weights of a network that is added to the loss while training
+// It is only applied to params that have Regularize set, which is weights but not biases
+type Regularizer interface {
+	// Penalty returns the penalty for a matrix of weights
+	Penalty(weights mat.Matrix) float64
+
+	// Gradient returns the gradient of the penalty with respect to the weights
+	Gradient(weights mat.Matrix) *mat.Dense
+}
+
+// L1 penalizes the sum of the absolute values of the weights, which pushes unimportant weights to exactly 0
+type L1 struct {
+	// Lambda is the strength of the penalty
+	Lambda float64
+}
+
+// Penalty returns lambda * sum(|w|)
+func (l L1) Penalty(weights mat.Matrix) float64 {
+	return l.Lambda * sumAbs(weights)
+}
+
+// Gradient returns lambda * sign(w)
+func (l L1) Gradient(weights mat.Matrix) *mat.Dense {
+	return apply(func(i, j int, v float64) float64 {
+		return l.Lambda * sign(v)
+	}, weights)
+}
+
+// L2 penalizes the sum of the squares of the weights, which keeps all the weights small
+type L2 struct {
+	// Lambda is the strength of the penalty
+	Lambda float64
+}
+
+// Penalty returns 0.5 * lambda * sum(w^2)
+func (l L2) Penalty(weights mat.Matrix) float64 {
+	return 0.5 * l.Lambda * sumSquares(weights)
+}
+
+// Gradient returns lambda * w
+func (l L2) Gradient(weights mat.Matrix) *mat.Dense {
+	return apply(func(i, j int, v float64) float64 {
+		return l.Lambda * v
+	}, weights)
+}
+
+// ElasticNet is a mix of the L1 and L2 penalties
+type ElasticNet struct {
+	// L1 and L2 are the strengths of the two penalties
+	L1 float64
+	L2 float64
+}
+
+// Penalty returns l1 * sum(|w|) + 0.5 * l2 * sum(w^2)
+func (e ElasticNet) Penalty(weights mat.Matrix) float64 {
+	return e.L1*sumAbs(weights) + 0.5*e.L2*sumSquares(weights)
+}
+
+// Gradient returns l1 * sign(w) + l2 * w
+func (e ElasticNet) Gradient(weights mat.Matrix) *mat.Dense {
+	return apply(func(i, j int, v float64) float64 {
+		return e.L1*sign(v) + e.L2*v
+	}, weights)
+}
+
+// sumAbs returns the sum of the absolute values in a matrix
+func sumAbs(m mat.Matrix) float64 {
+	r, c := m.Dims()
+	sum := 0.0
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			sum += math.Abs(m.At(i, j))
+		}
+	}
+	return sum
+}
+
+// sumSquares returns the sum of the squares of the values in a matrix
+func sumSquares(m mat.Matrix) float64 {
+	r, c := m.Dims()
+	sum := 0.0
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			sum += m.At(i, j) * m.At(i, j)
+		}
+	}
+	return sum
+}
+
+// sign returns -1, 0 or 1 depending on the sign of v
+func sign(v float64) float64 {
+	if v > 0 {
+		return 1
+	} else if v < 0 {
+		return -1
+	}
+	return 0
+}
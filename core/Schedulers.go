@@ -0,0 +1,226 @@
+package core
+
+import "math"
+
+// This file holds the learning rate schedulers that change the learning rate of a network while it trains
+
+// ScheduleUnit is what a scheduler counts when it changes the learning rate, updates or epochs
+type ScheduleUnit int
+
+const (
+	// PerStep schedulers change the learning rate after every update to the params
+	PerStep ScheduleUnit = iota
+
+	// PerEpoch schedulers change the learning rate at the end of every epoch
+	PerEpoch
+)
+
+// TrainingProgress is how far a network is through its training
+type TrainingProgress struct {
+	// Step is the number of updates that have been made to the params
+	Step int
+
+	// Epoch is the number of epochs that have been finished
+	Epoch int
+}
+
+// count returns the step or epoch depending on the unit
+func (p TrainingProgress) count(unit ScheduleUnit) int {
+	if unit == PerEpoch {
+		return p.Epoch
+	}
+	return p.Step
+}
+
+// Scheduler changes the learning rate of a network as it trains
+type Scheduler interface {
+	// Rate returns the learning rate to use at the given point in training
+	// baseRate is the learning rate the network was given
+	Rate(baseRate float64, progress TrainingProgress) float64
+}
+
+// MetricScheduler is a scheduler that also changes the learning rate based on a metric such as the validation loss
+type MetricScheduler interface {
+	Scheduler
+
+	// Observe is given the metric at the end of every epoch
+	Observe(metric float64)
+}
+
+// StepDecay multiplies the learning rate by Gamma every StepSize steps or epochs
+type StepDecay struct {
+	// StepSize is the number of steps or epochs between each decay
+	StepSize int
+
+	// Gamma is what the learning rate is multiplied by each decay
+	Gamma float64
+
+	// Unit is whether StepSize counts steps or epochs
+	Unit ScheduleUnit
+}
+
+// Rate returns baseRate * gamma^(count / stepSize)
+func (s StepDecay) Rate(baseRate float64, progress TrainingProgress) float64 {
+	if s.StepSize <= 0 {
+		return baseRate
+	}
+	return baseRate * math.Pow(s.Gamma, float64(progress.count(s.Unit)/s.StepSize))
+}
+
+// ExponentialDecay multiplies the learning rate by Gamma every step or epoch
+type ExponentialDecay struct {
+	// Gamma is what the learning rate is multiplied by every step or epoch
+	Gamma float64
+
+	// Unit is whether the rate decays every step or every epoch
+	Unit ScheduleUnit
+}
+
+// Rate returns baseRate * gamma^count
+func (e ExponentialDecay) Rate(baseRate float64, progress TrainingProgress) float64 {
+	return baseRate * math.Pow(e.Gamma, float64(progress.count(e.Unit)))
+}
+
+// CosineAnnealing lowers the learning rate from the base rate to MinRate along a cosine curve over Period steps or epochs
+// and then restarts from the base rate, each period is PeriodMultiplier times longer than the last
+type CosineAnnealing struct {
+	// Period is the length of the first cycle
+	Period int
+
+	// PeriodMultiplier is how much longer each cycle is than the one before, 0 or 1 keeps every cycle the same length
+	PeriodMultiplier float64
+
+	// MinRate is the learning rate at the end of each cycle
+	MinRate float64
+
+	// Unit is whether the cycles are counted in steps or epochs
+	Unit ScheduleUnit
+}
+
+// Rate returns the learning rate for the point in the current cycle
+func (c CosineAnnealing) Rate(baseRate float64, progress TrainingProgress) float64 {
+	if c.Period <= 0 {
+		return baseRate
+	}
+
+	// Find how far through the current cycle training is
+	t := float64(progress.count(c.Unit))
+	period := float64(c.Period)
+	for t >= period {
+		t -= period
+		if c.PeriodMultiplier > 1 {
+			period *= c.PeriodMultiplier
+		}
+	}
+
+	return c.MinRate + 0.5*(baseRate-c.MinRate)*(1+math.Cos(math.Pi*t/period))
+}
+
+// Warmup raises the learning rate linearly from close to 0 up to the base rate over Length steps or epochs
+// and then hands over to the After scheduler, which counts from the end of the warmup
+type Warmup struct {
+	// Length is the number of steps or epochs the warmup lasts
+	Length int
+
+	// Unit is whether Length counts steps or epochs
+	Unit ScheduleUnit
+
+	// After is the scheduler used once the warmup is over, the base rate is kept if it is nil
+	After Scheduler
+}
+
+// Rate returns the warmup rate during the warmup and the rate from After once it is over
+func (w Warmup) Rate(baseRate float64, progress TrainingProgress) float64 {
+	count := progress.count(w.Unit)
+	if count < w.Length {
+		return baseRate * float64(count+1) / float64(w.Length)
+	}
+	if w.After == nil {
+		return baseRate
+	}
+
+	// Shift the progress so the next scheduler starts counting when the warmup ends
+	if w.Unit == PerEpoch {
+		progress.Epoch -= w.Length
+	} else {
+		progress.Step -= w.Length
+	}
+	return w.After.Rate(baseRate, progress)
+}
+
+// Observe passes the metric on to the After scheduler if it uses one, including during the warmup
+func (w Warmup) Observe(metric float64) {
+	if m, ok := w.After.(MetricScheduler); ok {
+		m.Observe(metric)
+	}
+}
+
+// ReduceOnPlateau multiplies the learning rate by Factor when a metric such as the validation loss
+// hasn't improved for Patience epochs
+type ReduceOnPlateau struct {
+	// Factor is what the learning rate is multiplied by each time it is reduced
+	Factor float64
+
+	// Patience is the number of epochs without improvement that are allowed before the learning rate is reduced
+	Patience int
+
+	// MinDelta is how much the metric has to change by to count as an improvement
+	MinDelta float64
+
+	// MinRate is the lowest the learning rate will be reduced to
+	MinRate float64
+
+	// Maximize is true for metrics where higher is better such as accuracy, otherwise lower is better
+	Maximize bool
+
+	// scale is what the base rate is multiplied by, best is the best metric seen, seen is false until
+	// the first metric is observed and waited is the number of epochs since the best metric was seen
+	scale  float64
+	best   float64
+	seen   bool
+	waited int
+}
+
+// CreateReduceOnPlateau creates a scheduler that multiplies the learning rate by factor when the observed metric
+// hasn't gone down for patience epochs
+func CreateReduceOnPlateau(factor float64, patience int) *ReduceOnPlateau {
+	return &ReduceOnPlateau{
+		Factor:   factor,
+		Patience: patience,
+		scale:    1,
+	}
+}
+
+// Rate returns the base rate scaled down by every reduction so far
+// MinRate only limits the reductions, the rate is never raised above the base rate
+func (r *ReduceOnPlateau) Rate(baseRate float64, progress TrainingProgress) float64 {
+	if r.scale == 0 {
+		r.scale = 1
+	}
+	return math.Min(baseRate, math.Max(baseRate*r.scale, r.MinRate))
+}
+
+// Observe checks if the metric improved and reduces the learning rate if it hasn't for too long
+func (r *ReduceOnPlateau) Observe(metric float64) {
+	if r.scale == 0 {
+		r.scale = 1
+	}
+
+	improved := metric < r.best-r.MinDelta
+	if r.Maximize {
+		improved = metric > r.best+r.MinDelta
+	}
+
+	if !r.seen || improved {
+		r.best = metric
+		r.seen = true
+		r.waited = 0
+		return
+	}
+
+	r.waited++
+	if r.waited > r.Patience {
+		r.scale *= r.Factor
+		r.waited = 0
+	}
+}
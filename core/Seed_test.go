@@ -0,0 +1,109 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/shimmy568/GoNeuralNetworks/util"
+)
+
+// createSeedTestItems creates items with random inputs and one hot targets from the given seed
+func createSeedTestItems(seed int64) []*TrainingItem {
+	r := rand.New(rand.NewSource(seed))
+	items := make([]*TrainingItem, 40)
+	for i := range items {
+		inputs := mat.NewVecDense(5, nil)
+		for o := 0; o < 5; o++ {
+			inputs.SetVec(o, r.NormFloat64())
+		}
+		targets := mat.NewVecDense(3, nil)
+		targets.SetVec(r.Intn(3), 1)
+		items[i] = CreateTrainingItem(inputs, targets)
+	}
+	return items
+}
+
+// checkParamsEqual fails the test if any param of the two models isn't exactly the same
+func checkParamsEqual(t *testing.T, a []*Param, b []*Param) {
+	t.Helper()
+	if len(a) != len(b) {
+		t.Fatalf("Models have %d and %d params", len(a), len(b))
+	}
+	for i := range a {
+		if !mat.Equal(a[i].Value, b[i].Value) {
+			t.Errorf("Param %d is different between the two runs", i)
+		}
+	}
+}
+
+// TestSetSeedReproducible checks that seeding util.GetRand makes building and fitting a network with dropout
+// and shuffling give bit identical weights
+func TestSetSeedReproducible(t *testing.T) {
+	items := createSeedTestItems(1)
+	run := func() []*Param {
+		util.SetSeed(42)
+		n, err := CreateNetworkFromConfig(NetworkConfig{
+			LayerSizes:   []int{5, 8, 8, 3},
+			Activations:  []Activation{ReLU{}, Tanh{}, Softmax{}},
+			DropoutRates: []float64{0.3, 0.5},
+			LearningRate: 0.1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = n.Fit(items, FitConfig{Epochs: 3, BatchSize: 4, Shuffle: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n.model.Params()
+	}
+
+	checkParamsEqual(t, run(), run())
+}
+
+// TestInjectedRandReproducible checks that NetworkConfig.Rand, FitConfig.Rand and CreateDropoutWithSeed
+// make training reproducible on their own, whatever util.GetRand is seeded with
+func TestInjectedRandReproducible(t *testing.T) {
+	items := createSeedTestItems(1)
+
+	network := func(globalSeed int64) []*Param {
+		util.SetSeed(globalSeed)
+		n, err := CreateNetworkFromConfig(NetworkConfig{
+			LayerSizes:   []int{5, 8, 3},
+			Activations:  []Activation{ReLU{}, Softmax{}},
+			DropoutRates: []float64{0.5},
+			Rand:         rand.New(rand.NewSource(7)),
+			LearningRate: 0.1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = n.Fit(items, FitConfig{Epochs: 3, BatchSize: 4, Shuffle: true, Rand: rand.New(rand.NewSource(8))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n.model.Params()
+	}
+	checkParamsEqual(t, network(1), network(2))
+
+	sequential := func(globalSeed int64) []*Param {
+		util.SetSeed(globalSeed)
+		r := rand.New(rand.NewSource(7))
+		model := CreateSequential(
+			CreateDenseWithRand(5, 8, FanInUniform{}, r),
+			CreateActivationLayer(ReLU{}),
+			CreateDropoutWithSeed(0.5, 9),
+			CreateDenseWithRand(8, 3, FanInUniform{}, r),
+			CreateActivationLayer(Softmax{}),
+		)
+		model.LearningRate = 0.1
+		_, err := model.Fit(items, FitConfig{Epochs: 3, BatchSize: 4, Shuffle: true, Rand: rand.New(rand.NewSource(8))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return model.Params()
+	}
+	checkParamsEqual(t, sequential(1), sequential(2))
+}
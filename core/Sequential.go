@@ -0,0 +1,449 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds the Sequential model, a network made out of a list of layers that are run one after another
+
+// sequentialHeaderTag is the first value of the header line in model files saved by a Sequential
+const sequentialHeaderTag = "sequential"
+
+// Sequential is a network that runs its input through a list of layers in order
+type Sequential struct {
+	layers []Layer
+
+	// Loss is the loss function the network is trained to minimize
+	// If left nil it is CategoricalCrossEntropy when the last layer is a Softmax activation and MeanSquaredError otherwise
+	Loss Loss
+
+	// Optimizer is the update rule used to change the params while training, plain SGD is used if left nil
+	Optimizer Optimizer
+
+	// LearningRate is the learning rate used when training the network
+	// If there is a scheduler this is the base rate that it changes
+	LearningRate float64
+
+	// Scheduler optionally changes the learning rate as the network trains
+	Scheduler Scheduler
+
+	// progress is the number of updates and epochs the network has been trained for
+	progress TrainingProgress
+
+	// Regularizer is an optional penalty on the weights that is added to the loss and its gradient
+	Regularizer Regularizer
+
+	// WeightDecay is the fraction of each weight that is removed every update, scaled by the learning rate
+	// Unlike an L2 regularizer it is applied directly to the weights after the optimizer so it isn't scaled by adaptive optimizers
+	WeightDecay float64
+
+	// Clipper is an optional limit on the size of the gradients that is applied before the optimizer updates the params
+	Clipper Clipper
+
+	// clipCount is the number of training steps where the clipper changed the gradients
+	clipCount int
+
+	// lastLoss is the loss from the last training call
+	lastLoss float64
+}
+
+// CreateSequential creates a network out of the given layers
+func CreateSequential(layers ...Layer) *Sequential {
+	return &Sequential{
+		layers: layers,
+	}
+}
+
+// GetLayers returns the layers of the network in the order they are run
+func (s *Sequential) GetLayers() []Layer {
+	output := make([]Layer, len(s.layers))
+	copy(output, s.layers)
+	return output
+}
+
+// Params returns the learnable parameters of every layer in the network
+func (s *Sequential) Params() []*Param {
+	var params []*Param
+	for i := range s.layers {
+		params = append(params, s.layers[i].Params()...)
+	}
+	return params
+}
+
+// state returns the values of every layer in the network that are saved with the model but aren't learnable params
+func (s *Sequential) state() []*mat.Dense {
+	var state []*mat.Dense
+	for i := range s.layers {
+		if l, ok := s.layers[i].(statefulLayer); ok {
+			state = append(state, l.state()...)
+		}
+	}
+	return state
+}
+
+// copyWeights returns a copy of the value of every param and the state of every layer in the network
+func (s *Sequential) copyWeights() []*mat.Dense {
+	var values []*mat.Dense
+	for _, param := range s.Params() {
+		values = append(values, mat.DenseCopyOf(param.Value))
+	}
+	for _, state := range s.state() {
+		values = append(values, mat.DenseCopyOf(state))
+	}
+	return values
+}
+
+// setWeights copies values from copyWeights back into the params and the state of the network
+func (s *Sequential) setWeights(values []*mat.Dense) {
+	params := s.Params()
+	for i := range params {
+		params[i].Value.Copy(values[i])
+	}
+	state := s.state()
+	for i := range state {
+		state[i].Copy(values[len(params)+i])
+	}
+
+	s.paramsUpdated()
+}
+
+// paramsUpdated lets every layer know its params were changed from outside the layer
+func (s *Sequential) paramsUpdated() {
+	for i := range s.layers {
+		if l, ok := s.layers[i].(paramsUpdatedListener); ok {
+			l.paramsUpdated()
+		}
+	}
+}
+
+// GetLoss returns the loss function the network is trained with
+func (s *Sequential) GetLoss() Loss {
+	if s.Loss != nil {
+		return s.Loss
+	}
+
+	// Pick the loss that fits the output layer
+	if a, ok := s.layers[len(s.layers)-1].(*ActivationLayer); ok {
+		return defaultLoss(a.activation)
+	}
+	return MeanSquaredError{}
+}
+
+// getOptimizer returns the optimizer of the network, creating the default one the first time it is needed
+func (s *Sequential) getOptimizer() Optimizer {
+	if s.Optimizer == nil {
+		s.Optimizer = CreateSGD()
+	}
+	return s.Optimizer
+}
+
+// GetLastLoss returns the loss from the last call to Train, or the average loss from the last call to TrainBatch
+// It includes the penalty from the regularizer
+func (s *Sequential) GetLastLoss() float64 {
+	return s.lastLoss
+}
+
+// GetLearningRate returns the learning rate that the next update will use
+// It is the LearningRate changed by the scheduler, or just LearningRate if there isn't one
+func (s *Sequential) GetLearningRate() float64 {
+	if s.Scheduler == nil {
+		return s.LearningRate
+	}
+	return s.Scheduler.Rate(s.LearningRate, s.progress)
+}
+
+// GetProgress returns the number of updates and epochs the network has been trained for
+func (s *Sequential) GetProgress() TrainingProgress {
+	return s.progress
+}
+
+// EndEpoch marks the end of an epoch of training for schedulers that change the learning rate every epoch
+// metric is given to schedulers that use one such as ReduceOnPlateau, usually it is the validation loss
+func (s *Sequential) EndEpoch(metric float64) {
+	s.progress.Epoch++
+	if m, ok := s.Scheduler.(MetricScheduler); ok {
+		m.Observe(metric)
+	}
+}
+
+// GetClipCount returns the number of training steps where the gradients were clipped
+func (s *Sequential) GetClipCount() int {
+	return s.clipCount
+}
+
+// penalty returns the penalty the regularizer gives to the current weights of the network
+func (s *Sequential) penalty() float64 {
+	if s.Regularizer == nil {
+		return 0
+	}
+
+	params := s.Params()
+	sum := 0.0
+	for i := range params {
+		if params[i].Regularize {
+			sum += s.Regularizer.Penalty(params[i].Value)
+		}
+	}
+	return sum
+}
+
+// Forward runs a matrix of inputs with one sample per column through every layer of the network
+// training is the mode to run the layers in, there is no mode stored on the network. It should only be true
+// when Backward is going to be called on the layers after, which Train, TrainBatch and Fit do for every step,
+// while Predict, PredictBatch, ComputeLoss and evaluating validation data always run in inference mode
+func (s *Sequential) Forward(input *mat.Dense, training bool) *mat.Dense {
+	output := input
+	for i := range s.layers {
+		output = s.layers[i].Forward(output, training)
+	}
+	return output
+}
+
+// Predict takes a set of input data and generates a set of output values
+func (s *Sequential) Predict(inputData []float64) *mat.VecDense {
+	output := s.Forward(mat.NewDense(len(inputData), 1, inputData), false)
+	return mat.VecDenseCopyOf(output.ColView(0))
+}
+
+// PredictBatch runs a matrix of inputs with one sample per column through the network
+// and returns a matrix of outputs with one sample per column
+// Like Predict it never changes the network, so it is safe to call from many goroutines as long as nothing is training the network
+func (s *Sequential) PredictBatch(inputs mat.Matrix) *mat.Dense {
+	input, ok := inputs.(*mat.Dense)
+	if !ok {
+		input = mat.DenseCopyOf(inputs)
+	}
+	return s.Forward(input, false)
+}
+
+// Train is a function that is for one iteration of training using backpropagation
+// A single sample has no batch statistics, so batch normalization layers use their running statistics here
+// and only learn them from TrainBatch
+func (s *Sequential) Train(item *TrainingItem) error {
+	input := mat.NewDense(len(item.inputData), 1, item.inputData)
+	targets := mat.NewDense(len(item.expectedOutput), 1, item.expectedOutput)
+	s.lastLoss = s.trainMatrix(input, targets)
+
+	return nil
+}
+
+// TrainBatch trains the network on a set of training data in mini-batches of batchSize items
+// Each batch is run through the network as one matrix and the params are updated once per batch
+// using the gradient averaged over the batch, the last batch is smaller if the items don't divide evenly
+func (s *Sequential) TrainBatch(trainingData []*TrainingItem, batchSize int) error {
+	if batchSize <= 0 {
+		return errors.New("Batch size must be at least 1")
+	}
+
+	// Check all the items are the same size before any training is done
+	err := checkTrainingItems(trainingData)
+	if err != nil {
+		return err
+	}
+
+	s.trainBatches(trainingData, batchSize, s.trainMatrix)
+
+	return nil
+}
+
+// trainBatches trains on the items in batches of batchSize using step to train on each batch and keeps the average loss
+func (s *Sequential) trainBatches(trainingData []*TrainingItem, batchSize int, step func(input *mat.Dense, targets *mat.Dense) float64) {
+	totalLoss := 0.0
+	for start := 0; start < len(trainingData); start += batchSize {
+		end := start + batchSize
+		if end > len(trainingData) {
+			end = len(trainingData)
+		}
+
+		// Train on the batch and weight its loss by the number of items in it
+		input, targets := stackTrainingItems(trainingData[start:end])
+		totalLoss += step(input, targets) * float64(end-start)
+	}
+
+	// Keep track of the average loss over all the items
+	if len(trainingData) > 0 {
+		s.lastLoss = totalLoss / float64(len(trainingData))
+	}
+}
+
+// ComputeLoss returns the average loss of the network over a set of data without training on it
+// It includes the penalty from the regularizer
+func (s *Sequential) ComputeLoss(data []*TrainingItem) (float64, error) {
+	err := checkTrainingItems(data)
+	if err != nil {
+		return 0, err
+	}
+
+	loss, _ := s.evaluate(data, nil)
+	return loss, nil
+}
+
+// trainMatrix does one step of backpropagation on a batch of samples with one sample per column
+// The gradients are averaged over the batch and a single update is made to the params, the loss is returned
+func (s *Sequential) trainMatrix(input *mat.Dense, targets *mat.Dense) float64 {
+	lossValue := s.computeGradients(input, targets)
+	s.applyGradients()
+
+	return lossValue
+}
+
+// computeGradients runs a batch of samples forwards and backwards through the network setting the gradients of all the params
+// without updating them, the loss is returned
+func (s *Sequential) computeGradients(input *mat.Dense, targets *mat.Dense) float64 {
+	// Do the forward propagation step in training mode, the layers keep what they need for the backpropagation step
+	output := s.Forward(input, true)
+
+	loss := s.GetLoss()
+	lossValue := loss.Value(output, targets) + s.penalty()
+
+	s.backward(loss, output, targets)
+
+	return lossValue
+}
+
+// backward runs the gradient of the loss back through every layer of the network setting the gradients of all the params
+func (s *Sequential) backward(loss Loss, output *mat.Dense, targets *mat.Dense) {
+	last := len(s.layers) - 1
+	gradient := loss.Gradient(output, targets)
+
+	// Skip the output activation if its gradient can be found directly from the loss
+	if a, ok := s.layers[last].(*ActivationLayer); ok {
+		if fused, ok := fusedOutputGradient(loss, a.activation, output, targets); ok {
+			gradient = fused
+			last--
+		}
+	}
+
+	// Work from the output layer back to the first layer
+	for i := last; i >= 0; i-- {
+		gradient = s.layers[i].Backward(gradient)
+	}
+}
+
+// applyGradients has the optimizer update every param of the network using the gradients from backpropagation
+// The gradient of the regularizer is added to the weights' gradients and the gradients are clipped first,
+// weight decay is applied after
+func (s *Sequential) applyGradients() {
+	layerParams := make([][]*Param, len(s.layers))
+	for i := range s.layers {
+		layerParams[i] = s.layers[i].Params()
+		for _, param := range layerParams[i] {
+			if s.Regularizer != nil && param.Regularize {
+				param.Grad.Add(param.Grad, s.Regularizer.Gradient(param.Value))
+			}
+		}
+	}
+
+	if s.Clipper != nil && s.Clipper.Clip(layerParams) {
+		s.clipCount++
+	}
+
+	optimizer := s.getOptimizer()
+	learningRate := s.GetLearningRate()
+	for i := range s.layers {
+		params := layerParams[i]
+		for o := range params {
+			optimizer.Update(params[o], learningRate)
+
+			if s.WeightDecay != 0 && params[o].Regularize {
+				params[o].Value.Scale(1-learningRate*s.WeightDecay, params[o].Value)
+			}
+		}
+
+		if l, ok := s.layers[i].(paramsUpdatedListener); ok && len(params) > 0 {
+			l.paramsUpdated()
+		}
+	}
+
+	s.progress.Step++
+}
+
+// SaveWeights saves the params of every layer in the network to a file on disk
+// The state of layers such as the running statistics of batch normalization is saved after the params
+func (s *Sequential) SaveWeights(path string) error {
+	dataFile, err := os.OpenFile(path, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0662)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(dataFile)
+	defer dataFile.Close()
+
+	// Write the number of params and state values in the first row and then each of them on its own row
+	params := s.Params()
+	state := s.state()
+	writer.Write([]byte(sequentialHeaderTag + "," + strconv.Itoa(len(params)) + "," + strconv.Itoa(len(state)) + "\n"))
+	for i := range params {
+		writer.Write([]byte(denseToCSVLine(params[i].Value)))
+	}
+	for i := range state {
+		writer.Write([]byte(denseToCSVLine(state[i])))
+	}
+
+	return writer.Flush()
+}
+
+// LoadWeights loads the params of every layer in the network from a file saved by SaveWeights
+// The network needs to have the same layers as the one that was saved
+func (s *Sequential) LoadWeights(path string) error {
+	rawData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(rawData), "\n")
+
+	// Check the number of params and state values in the file matches the network
+	params := s.Params()
+	state := s.state()
+	header := strings.Split(lines[0], ",")
+	if header[0] != sequentialHeaderTag || len(header) != 3 {
+		return errors.New("Model file wasn't saved by a sequential network")
+	}
+	count, err := strconv.Atoi(header[1])
+	if err != nil {
+		return err
+	}
+	stateCount, err := strconv.Atoi(header[2])
+	if err != nil {
+		return err
+	}
+	if count != len(params) || stateCount != len(state) || len(lines) < count+stateCount+1 {
+		return errors.New("Number of params in loaded model doesn't match the network")
+	}
+
+	// Read each param and then each state value into the network
+	for i := range params {
+		err = parseCSVLineIntoDense(lines[i+1], params[i].Value)
+		if err != nil {
+			return err
+		}
+	}
+	for i := range state {
+		err = parseCSVLineIntoDense(lines[count+i+1], state[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	s.paramsUpdated()
+
+	return nil
+}
+
+// checkTrainingItems checks that all the items have the same number of inputs and expected outputs
+func checkTrainingItems(items []*TrainingItem) error {
+	for i := range items {
+		if len(items[i].inputData) != len(items[0].inputData) || len(items[i].expectedOutput) != len(items[0].expectedOutput) {
+			return fmt.Errorf("Dimensions of data item %d don't match the rest of the data", i)
+		}
+	}
+
+	return nil
+}
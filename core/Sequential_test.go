@@ -0,0 +1,69 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// TestSequentialWeightsRoundTrip checks that weights saved by one network load into another with the same layers
+func TestSequentialWeightsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.csv")
+	saved := createParallelTestModel(1)
+	err := saved.SaveWeights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := createParallelTestModel(2)
+	err = loaded.LoadWeights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkParamsEqual(t, saved.Params(), loaded.Params())
+}
+
+// TestSequentialLoadWeightsHeader checks that files without both the param and state counts in the header are rejected
+func TestSequentialLoadWeightsHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.csv")
+	err := createParallelTestModel(1).SaveWeights(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawData, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the state count from the header
+	header := []byte(sequentialHeaderTag + ",4,0\n")
+	if !bytes.HasPrefix(rawData, header) {
+		t.Fatalf("Saved header isn't %q", header)
+	}
+	err = ioutil.WriteFile(path, append([]byte(sequentialHeaderTag+",4\n"), rawData[len(header):]...), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = createParallelTestModel(1).LoadWeights(path); err == nil {
+		t.Error("LoadWeights didn't return an error for a header without the state count")
+	}
+}
+
+// TestComputeLossChunks checks that the loss over more items than fit in one evaluation chunk
+// is the same as the loss of the whole set run as one batch
+func TestComputeLossChunks(t *testing.T) {
+	model := createParallelTestModel(1)
+	items := createParallelTestItems(1, 2*evaluationBatchSize+10)
+
+	got, err := model.ComputeLoss(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, targets := stackTrainingItems(items)
+	want := model.GetLoss().Value(model.Forward(input, false), targets) + model.penalty()
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("ComputeLoss = %f, want %f", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// This file holds snapshots, read only copies of a network that can be used for inference while the network keeps training
+
+// Snapshot is a copy of a network's layers and weights at one point in time that is only used for inference
+// Nothing changes a snapshot once it has been made, so any number of goroutines can call Predict and PredictBatch
+// at the same time, even while the network it was taken from is training
+type Snapshot struct {
+	model *Sequential
+}
+
+// Snapshot makes a copy of the network for inference
+// It reads the weights so it can't be called while the network is training, such as from another goroutine during Fit,
+// but it can be called from a callback, see SnapshotPublisher
+func (s *Sequential) Snapshot() (*Snapshot, error) {
+	layers := make([]Layer, len(s.layers))
+	for i := range s.layers {
+		if l, ok := s.layers[i].(snapshotLayer); ok {
+			layers[i] = l.snapshotCopy()
+			continue
+		}
+
+		l, ok := s.layers[i].(workerLayer)
+		if !ok {
+			return nil, errors.New("Layers of the network can't be copied into a snapshot")
+		}
+		layers[i] = l.createWorker()
+	}
+
+	// The copied layers share the values of their params with the network so give them their own copies
+	model := CreateSequential(layers...)
+	for _, param := range model.Params() {
+		param.Value = mat.DenseCopyOf(param.Value)
+	}
+	model.paramsUpdated()
+
+	return &Snapshot{model: model}, nil
+}
+
+// Predict takes a set of input data and generates a set of output values
+func (s *Snapshot) Predict(inputData []float64) *mat.VecDense {
+	return s.model.Predict(inputData)
+}
+
+// PredictBatch runs a matrix of inputs with one sample per column through the network
+// and returns a matrix of outputs with one sample per column
+func (s *Snapshot) PredictBatch(inputs mat.Matrix) *mat.Dense {
+	return s.model.PredictBatch(inputs)
+}
+
+// SnapshotPublisher is a callback that keeps an up to date snapshot of the network while it is fitted
+// so predictions can be served from other goroutines during training
+type SnapshotPublisher struct {
+	BaseCallback
+
+	// Every is the number of batches between each new snapshot, 0 only takes a snapshot at the end of every epoch
+	Every int
+
+	current atomic.Value
+}
+
+// CreateSnapshotPublisher creates a callback that takes a new snapshot every given number of batches and at the end of every epoch
+func CreateSnapshotPublisher(every int) *SnapshotPublisher {
+	return &SnapshotPublisher{Every: every}
+}
+
+// Get returns the latest snapshot, or nil if training hasn't started yet
+// It is safe to call from any goroutine
+func (p *SnapshotPublisher) Get() *Snapshot {
+	snapshot, _ := p.current.Load().(*Snapshot)
+	return snapshot
+}
+
+// publish takes a snapshot of the network and makes it the one returned by Get
+// If the network can't be copied training is stopped and Fit returns the error
+func (p *SnapshotPublisher) publish(info *TrainingInfo) {
+	snapshot, err := info.Network.Snapshot()
+	if err != nil {
+		if info.Err == nil {
+			info.Err = err
+		}
+		return
+	}
+	p.current.Store(snapshot)
+}
+
+// OnTrainBegin takes the first snapshot before any training is done
+func (p *SnapshotPublisher) OnTrainBegin(info *TrainingInfo) {
+	p.publish(info)
+}
+
+// OnBatchEnd takes a new snapshot every few batches
+func (p *SnapshotPublisher) OnBatchEnd(info *TrainingInfo) {
+	if p.Every > 0 && (info.Batch+1)%p.Every == 0 {
+		p.publish(info)
+	}
+}
+
+// OnEpochEnd takes a new snapshot
+func (p *SnapshotPublisher) OnEpochEnd(info *TrainingInfo) {
+	p.publish(info)
+}
+
+// OnTrainEnd takes a snapshot of the final weights, which are different if early stopping restored the best weights
+func (p *SnapshotPublisher) OnTrainEnd(info *TrainingInfo) {
+	p.publish(info)
+}
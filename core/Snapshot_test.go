@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// uncopyableLayer is a dense layer that can't be copied into a snapshot
+type uncopyableLayer struct {
+	dense *Dense
+}
+
+func (l *uncopyableLayer) Forward(input *mat.Dense, training bool) *mat.Dense {
+	return l.dense.Forward(input, training)
+}
+
+func (l *uncopyableLayer) Backward(grad *mat.Dense) *mat.Dense {
+	return l.dense.Backward(grad)
+}
+
+func (l *uncopyableLayer) Params() []*Param {
+	return l.dense.Params()
+}
+
+// TestSnapshotPublisherError checks that Fit stops and returns the error when the publisher can't take a snapshot
+func TestSnapshotPublisherError(t *testing.T) {
+	model := CreateSequential(&uncopyableLayer{dense: CreateDense(5, 3)})
+	model.LearningRate = 0.1
+
+	publisher := CreateSnapshotPublisher(1)
+	history, err := model.Fit(createSeedTestItems(1), FitConfig{Epochs: 3, BatchSize: 4, Callbacks: []Callback{publisher}})
+	if err == nil {
+		t.Fatal("Fit didn't return an error")
+	}
+	if len(history.Epochs) != 0 {
+		t.Errorf("Fit trained %d epochs after the error", len(history.Epochs))
+	}
+	if publisher.Get() != nil {
+		t.Error("Publisher has a snapshot")
+	}
+}
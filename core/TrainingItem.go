@@ -34,3 +34,17 @@ func CreateTrainingItem(inputData *mat.VecDense, expectedData *mat.VecDense) (ou
 
 	return output
 }
+
+// stackTrainingItems puts the inputs and the expected outputs of a set of training items into two matrices
+// with one item per column so that they can be run through the network as a batch
+func stackTrainingItems(items []*TrainingItem) (inputs *mat.Dense, targets *mat.Dense) {
+	inputs = mat.NewDense(len(items[0].inputData), len(items), nil)
+	targets = mat.NewDense(len(items[0].expectedOutput), len(items), nil)
+
+	for i := range items {
+		inputs.SetCol(i, items[i].inputData)
+		targets.SetCol(i, items[i].expectedOutput)
+	}
+
+	return inputs, targets
+}
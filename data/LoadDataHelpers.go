@@ -3,6 +3,7 @@ package data
 import (
 	"bufio"
 	"errors"
+	"math/rand"
 	"os"
 	"path/filepath"
 
@@ -37,8 +38,12 @@ func ListFilesDir(filePath string) ([]string, error) {
 // pathsA will contain len(paths) * ratio elements it it
 // ratio must be 0 <= ratio <= 1 or will return error
 func SegmentDataSet(paths []string, ratio float64) (pathsA []string, pathsB []string, err error) {
+	return SegmentDataSetWithRand(paths, ratio, util.GetRand())
+}
+
+// SegmentDataSetWithRand is SegmentDataSet using the given random number generator to pick the elements of pathsA
+func SegmentDataSetWithRand(paths []string, ratio float64, r *rand.Rand) (pathsA []string, pathsB []string, err error) {
 	indices := make(map[int]bool) // The map that will hold the indices for pathsA
-	r := util.GetRand()
 
 	// Check that ratio value is valid
 	if ratio < 0 || ratio > 1 {
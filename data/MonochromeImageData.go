@@ -64,6 +64,13 @@ func (m *MonochromeImageData) GetDense() *mat.Dense {
 	return data
 }
 
+// GetVector returns the same values as GetDense as a vector with the rows of the image one after another,
+// so pixel (x, y) is at y*Width + x
+func (m *MonochromeImageData) GetVector() *mat.VecDense {
+	dense := m.GetDense()
+	return mat.NewVecDense(m.Width*m.Height, dense.RawMatrix().Data)
+}
+
 // getPixelBrightnessLevel converts a color to a brightness value
 func getPixelBrightnessLevel(c color.Color) float64 {
 	r, g, b, _ := c.RGBA()
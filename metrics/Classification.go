@@ -0,0 +1,270 @@
+// Package metrics holds functions for measuring how well a classifier did from its predictions and the true labels
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// This file holds the metrics that only look at the predicted class of each sample
+
+// ArgMax returns the index of the highest score, or -1 if there are no scores
+// It is used to turn the outputs of a network into the class it predicted
+func ArgMax(scores []float64) int {
+	best := -1
+	for i := range scores {
+		if best == -1 || scores[i] > scores[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// Predictions returns the predicted class of every sample from its scores
+func Predictions(scores [][]float64) []int {
+	predicted := make([]int, len(scores))
+	for i := range scores {
+		predicted[i] = ArgMax(scores[i])
+	}
+	return predicted
+}
+
+// ConfusionMatrix counts how often each class was predicted as each other class
+// Counts[actual][predicted] is the number of samples of class actual that were predicted as class predicted
+type ConfusionMatrix struct {
+	Counts [][]int `json:"counts"`
+}
+
+// CreateConfusionMatrix creates a confusion matrix from the predicted and actual class of every sample
+func CreateConfusionMatrix(predicted []int, actual []int, classCount int) (*ConfusionMatrix, error) {
+	if len(predicted) != len(actual) {
+		return nil, errors.New("Number of predictions doesn't match the number of labels")
+	}
+	if classCount <= 0 {
+		return nil, errors.New("There needs to be at least one class")
+	}
+
+	counts := make([][]int, classCount)
+	for i := range counts {
+		counts[i] = make([]int, classCount)
+	}
+
+	for i := range predicted {
+		if predicted[i] < 0 || predicted[i] >= classCount || actual[i] < 0 || actual[i] >= classCount {
+			return nil, fmt.Errorf("Class of sample %d is outside the range of classes", i)
+		}
+		counts[actual[i]][predicted[i]]++
+	}
+
+	return &ConfusionMatrix{Counts: counts}, nil
+}
+
+// ClassCount returns the number of classes in the matrix
+func (c *ConfusionMatrix) ClassCount() int {
+	return len(c.Counts)
+}
+
+// Total returns the number of samples in the matrix
+func (c *ConfusionMatrix) Total() int {
+	total := 0
+	for i := range c.Counts {
+		for j := range c.Counts[i] {
+			total += c.Counts[i][j]
+		}
+	}
+	return total
+}
+
+// Accuracy returns the fraction of samples that were predicted correctly
+func (c *ConfusionMatrix) Accuracy() float64 {
+	correct := 0
+	for i := range c.Counts {
+		correct += c.Counts[i][i]
+	}
+	return ratio(correct, c.Total())
+}
+
+// TruePositives returns the number of samples of the class that were predicted as the class
+func (c *ConfusionMatrix) TruePositives(class int) int {
+	return c.Counts[class][class]
+}
+
+// FalsePositives returns the number of samples of other classes that were predicted as the class
+func (c *ConfusionMatrix) FalsePositives(class int) int {
+	count := 0
+	for i := range c.Counts {
+		if i != class {
+			count += c.Counts[i][class]
+		}
+	}
+	return count
+}
+
+// FalseNegatives returns the number of samples of the class that were predicted as another class
+func (c *ConfusionMatrix) FalseNegatives(class int) int {
+	count := 0
+	for j := range c.Counts[class] {
+		if j != class {
+			count += c.Counts[class][j]
+		}
+	}
+	return count
+}
+
+// Support returns the number of samples of the class
+func (c *ConfusionMatrix) Support(class int) int {
+	return c.TruePositives(class) + c.FalseNegatives(class)
+}
+
+// Precision returns the fraction of samples predicted as the class that really are the class
+func (c *ConfusionMatrix) Precision(class int) float64 {
+	tp := c.TruePositives(class)
+	return ratio(tp, tp+c.FalsePositives(class))
+}
+
+// Recall returns the fraction of samples of the class that were predicted as the class
+func (c *ConfusionMatrix) Recall(class int) float64 {
+	tp := c.TruePositives(class)
+	return ratio(tp, tp+c.FalseNegatives(class))
+}
+
+// F1 returns the harmonic mean of the precision and recall of the class
+func (c *ConfusionMatrix) F1(class int) float64 {
+	return f1(c.Precision(class), c.Recall(class))
+}
+
+// MacroPrecision returns the precision of every class averaged with each class weighted the same
+func (c *ConfusionMatrix) MacroPrecision() float64 {
+	return c.macro(c.Precision)
+}
+
+// MacroRecall returns the recall of every class averaged with each class weighted the same
+func (c *ConfusionMatrix) MacroRecall() float64 {
+	return c.macro(c.Recall)
+}
+
+// MacroF1 returns the F1 score of every class averaged with each class weighted the same
+func (c *ConfusionMatrix) MacroF1() float64 {
+	return c.macro(c.F1)
+}
+
+// WeightedPrecision returns the precision of every class averaged with each class weighted by its support
+func (c *ConfusionMatrix) WeightedPrecision() float64 {
+	return c.weighted(c.Precision)
+}
+
+// WeightedRecall returns the recall of every class averaged with each class weighted by its support
+func (c *ConfusionMatrix) WeightedRecall() float64 {
+	return c.weighted(c.Recall)
+}
+
+// WeightedF1 returns the F1 score of every class averaged with each class weighted by its support
+func (c *ConfusionMatrix) WeightedF1() float64 {
+	return c.weighted(c.F1)
+}
+
+// MicroPrecision returns the precision found from the true and false positives of every class added together
+func (c *ConfusionMatrix) MicroPrecision() float64 {
+	tp, fp := 0, 0
+	for i := range c.Counts {
+		tp += c.TruePositives(i)
+		fp += c.FalsePositives(i)
+	}
+	return ratio(tp, tp+fp)
+}
+
+// MicroRecall returns the recall found from the true positives and false negatives of every class added together
+func (c *ConfusionMatrix) MicroRecall() float64 {
+	tp, fn := 0, 0
+	for i := range c.Counts {
+		tp += c.TruePositives(i)
+		fn += c.FalseNegatives(i)
+	}
+	return ratio(tp, tp+fn)
+}
+
+// MicroF1 returns the harmonic mean of the micro precision and micro recall
+// When every sample has exactly one class this is the same as the accuracy
+func (c *ConfusionMatrix) MicroF1() float64 {
+	return f1(c.MicroPrecision(), c.MicroRecall())
+}
+
+// macro averages a per class metric over every class
+func (c *ConfusionMatrix) macro(metric func(class int) float64) float64 {
+	sum := 0.0
+	for i := range c.Counts {
+		sum += metric(i)
+	}
+	return sum / float64(len(c.Counts))
+}
+
+// weighted averages a per class metric over every class weighted by the number of samples of each class
+func (c *ConfusionMatrix) weighted(metric func(class int) float64) float64 {
+	total := c.Total()
+	if total == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for i := range c.Counts {
+		sum += metric(i) * float64(c.Support(i))
+	}
+	return sum / float64(total)
+}
+
+// TopKAccuracy returns the fraction of samples where the actual class is one of the k classes with the highest scores
+func TopKAccuracy(scores [][]float64, actual []int, k int) (float64, error) {
+	if len(scores) != len(actual) {
+		return 0, errors.New("Number of predictions doesn't match the number of labels")
+	}
+	if k <= 0 {
+		return 0, errors.New("k must be at least 1")
+	}
+
+	correct := 0
+	for i := range scores {
+		if actual[i] < 0 || actual[i] >= len(scores[i]) {
+			return 0, fmt.Errorf("Class of sample %d is outside the range of classes", i)
+		}
+
+		// The class is in the top k if fewer than k classes have a higher score
+		higher := 0
+		for j := range scores[i] {
+			if scores[i][j] > scores[i][actual[i]] {
+				higher++
+			}
+		}
+		if higher < k {
+			correct++
+		}
+	}
+
+	return ratio(correct, len(scores)), nil
+}
+
+// sortedIndices returns the indices of the values from highest to lowest
+func sortedIndices(values []float64) []int {
+	indices := make([]int, len(values))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool { return values[indices[a]] > values[indices[b]] })
+	return indices
+}
+
+// ratio returns a / b, or 0 if b is 0
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// f1 returns the harmonic mean of a precision and a recall, or 0 if both are 0
+func f1(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0
+	}
+	return 2 * precision * recall / (precision + recall)
+}
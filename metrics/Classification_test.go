@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo returns true if two values are the same up to rounding
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
+
+// TestConfusionMatrix checks the counts and every per class and averaged metric on a hand worked example
+// Class 3 has no samples and is never predicted so all of its metrics are 0
+func TestConfusionMatrix(t *testing.T) {
+	predicted := []int{0, 0, 1, 1, 2, 1}
+	actual := []int{0, 1, 1, 1, 2, 0}
+	c, err := CreateConfusionMatrix(predicted, actual, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := [][]int{{1, 1, 0, 0}, {1, 2, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 0}}
+	for i := range counts {
+		for j := range counts[i] {
+			if c.Counts[i][j] != counts[i][j] {
+				t.Errorf("Counts[%d][%d] = %d, want %d", i, j, c.Counts[i][j], counts[i][j])
+			}
+		}
+	}
+	if c.Total() != 6 {
+		t.Errorf("Total() = %d, want 6", c.Total())
+	}
+
+	classes := []struct {
+		tp, fp, fn, support   int
+		precision, recall, f1 float64
+	}{
+		{1, 1, 1, 2, 0.5, 0.5, 0.5},
+		{2, 1, 1, 3, 2.0 / 3, 2.0 / 3, 2.0 / 3},
+		{1, 0, 0, 1, 1, 1, 1},
+		{0, 0, 0, 0, 0, 0, 0},
+	}
+	for class, want := range classes {
+		if c.TruePositives(class) != want.tp || c.FalsePositives(class) != want.fp || c.FalseNegatives(class) != want.fn || c.Support(class) != want.support {
+			t.Errorf("Class %d: tp %d fp %d fn %d support %d, want %d %d %d %d", class,
+				c.TruePositives(class), c.FalsePositives(class), c.FalseNegatives(class), c.Support(class), want.tp, want.fp, want.fn, want.support)
+		}
+		if !closeTo(c.Precision(class), want.precision) || !closeTo(c.Recall(class), want.recall) || !closeTo(c.F1(class), want.f1) {
+			t.Errorf("Class %d: precision %f recall %f f1 %f, want %f %f %f", class,
+				c.Precision(class), c.Recall(class), c.F1(class), want.precision, want.recall, want.f1)
+		}
+	}
+
+	averages := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Accuracy", c.Accuracy(), 4.0 / 6},
+		{"MacroPrecision", c.MacroPrecision(), (0.5 + 2.0/3 + 1) / 4},
+		{"MacroRecall", c.MacroRecall(), (0.5 + 2.0/3 + 1) / 4},
+		{"MacroF1", c.MacroF1(), (0.5 + 2.0/3 + 1) / 4},
+		{"WeightedPrecision", c.WeightedPrecision(), (0.5*2 + 2.0/3*3 + 1) / 6},
+		{"WeightedRecall", c.WeightedRecall(), (0.5*2 + 2.0/3*3 + 1) / 6},
+		{"WeightedF1", c.WeightedF1(), (0.5*2 + 2.0/3*3 + 1) / 6},
+		{"MicroPrecision", c.MicroPrecision(), 4.0 / 6},
+		{"MicroRecall", c.MicroRecall(), 4.0 / 6},
+		{"MicroF1", c.MicroF1(), 4.0 / 6},
+	}
+	for _, a := range averages {
+		if !closeTo(a.got, a.want) {
+			t.Errorf("%s() = %f, want %f", a.name, a.got, a.want)
+		}
+	}
+}
+
+// TestCreateConfusionMatrixErrors checks that bad input is rejected
+func TestCreateConfusionMatrixErrors(t *testing.T) {
+	tests := []struct {
+		name              string
+		predicted, actual []int
+		classCount        int
+	}{
+		{"length mismatch", []int{0, 1}, []int{0}, 2},
+		{"no classes", []int{}, []int{}, 0},
+		{"predicted out of range", []int{2}, []int{0}, 2},
+		{"actual out of range", []int{0}, []int{-1}, 2},
+	}
+	for _, test := range tests {
+		if _, err := CreateConfusionMatrix(test.predicted, test.actual, test.classCount); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+// TestArgMax checks that ties go to the first index and that no scores gives -1
+func TestArgMax(t *testing.T) {
+	tests := []struct {
+		scores []float64
+		want   int
+	}{
+		{[]float64{0.1, 0.7, 0.2}, 1},
+		{[]float64{0.5, 0.5}, 0},
+		{[]float64{-3}, 0},
+		{nil, -1},
+	}
+	for _, test := range tests {
+		if got := ArgMax(test.scores); got != test.want {
+			t.Errorf("ArgMax(%v) = %d, want %d", test.scores, got, test.want)
+		}
+	}
+}
+
+// TestTopKAccuracy checks the top k accuracy for every k on a hand worked example
+// The actual classes are ranked 2nd, 1st and 3rd
+func TestTopKAccuracy(t *testing.T) {
+	scores := [][]float64{{0.1, 0.7, 0.2}, {0.5, 0.3, 0.2}, {0.2, 0.3, 0.5}}
+	actual := []int{2, 0, 0}
+
+	for k, want := range map[int]float64{1: 1.0 / 3, 2: 2.0 / 3, 3: 1} {
+		got, err := TopKAccuracy(scores, actual, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !closeTo(got, want) {
+			t.Errorf("TopKAccuracy(k = %d) = %f, want %f", k, got, want)
+		}
+	}
+
+	if _, err := TopKAccuracy(scores, actual, 0); err == nil {
+		t.Error("k of 0 didn't return an error")
+	}
+	if _, err := TopKAccuracy(scores, []int{0, 3, 0}, 1); err == nil {
+		t.Error("Class out of range didn't return an error")
+	}
+}
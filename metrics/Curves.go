@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// This file holds the ROC and precision recall curves that look at the scores of a class instead of just the predicted class
+
+// Curve is a curve made by sweeping a threshold over the scores of a class
+// Point i is found by counting every sample with a score of at least Thresholds[i] as a positive
+type Curve struct {
+	X          []float64 `json:"x"`
+	Y          []float64 `json:"y"`
+	Thresholds []float64 `json:"thresholds"`
+
+	// AUC is the area under the curve found with the trapezoidal rule
+	// It is NaN when the curve isn't defined, such as the ROC curve of a class without any positive samples,
+	// and is written as null in json
+	AUC float64 `json:"auc"`
+}
+
+// MarshalJSON writes the curve as json with an AUC of null when it is NaN, which json can't hold
+func (c Curve) MarshalJSON() ([]byte, error) {
+	type curve Curve
+	var auc *float64
+	if !math.IsNaN(c.AUC) {
+		auc = &c.AUC
+	}
+	return json.Marshal(struct {
+		curve
+		AUC *float64 `json:"auc"`
+	}{curve(c), auc})
+}
+
+// ROCCurve returns the receiver operating characteristic curve of a binary problem
+// X is the false positive rate and Y is the true positive rate
+// The rates need both positive and negative samples, without them the AUC is NaN
+func ROCCurve(scores []float64, positive []bool) (Curve, error) {
+	points, err := thresholdSweep(scores, positive)
+	if err != nil {
+		return Curve{}, err
+	}
+
+	positives, negatives := points[len(points)-1].tp, points[len(points)-1].fp
+	curve := Curve{}
+	for _, p := range points {
+		curve.X = append(curve.X, ratio(p.fp, negatives))
+		curve.Y = append(curve.Y, ratio(p.tp, positives))
+		curve.Thresholds = append(curve.Thresholds, p.threshold)
+	}
+	curve.AUC = trapezoid(curve.X, curve.Y)
+	if positives == 0 || negatives == 0 {
+		curve.AUC = math.NaN()
+	}
+
+	return curve, nil
+}
+
+// PRCurve returns the precision recall curve of a binary problem
+// X is the recall and Y is the precision
+// The recall needs positive samples, without them the AUC is NaN
+func PRCurve(scores []float64, positive []bool) (Curve, error) {
+	points, err := thresholdSweep(scores, positive)
+	if err != nil {
+		return Curve{}, err
+	}
+
+	positives := points[len(points)-1].tp
+	curve := Curve{}
+	for i, p := range points {
+		// The first point has no predicted positives, its precision is taken to be 1 so the curve starts at the top
+		precision := 1.0
+		if i > 0 {
+			precision = ratio(p.tp, p.tp+p.fp)
+		}
+
+		curve.X = append(curve.X, ratio(p.tp, positives))
+		curve.Y = append(curve.Y, precision)
+		curve.Thresholds = append(curve.Thresholds, p.threshold)
+	}
+	curve.AUC = trapezoid(curve.X, curve.Y)
+	if positives == 0 {
+		curve.AUC = math.NaN()
+	}
+
+	return curve, nil
+}
+
+// OneVsRestROCCurve returns the ROC curve of one class of a multi-class problem, treating every other class as negative
+func OneVsRestROCCurve(scores [][]float64, actual []int, class int) (Curve, error) {
+	classScores, positive, err := oneVsRest(scores, actual, class)
+	if err != nil {
+		return Curve{}, err
+	}
+	return ROCCurve(classScores, positive)
+}
+
+// OneVsRestPRCurve returns the precision recall curve of one class of a multi-class problem, treating every other class as negative
+func OneVsRestPRCurve(scores [][]float64, actual []int, class int) (Curve, error) {
+	classScores, positive, err := oneVsRest(scores, actual, class)
+	if err != nil {
+		return Curve{}, err
+	}
+	return PRCurve(classScores, positive)
+}
+
+// oneVsRest turns a multi-class problem into a binary one for a single class
+func oneVsRest(scores [][]float64, actual []int, class int) ([]float64, []bool, error) {
+	if len(scores) != len(actual) {
+		return nil, nil, errors.New("Number of predictions doesn't match the number of labels")
+	}
+
+	classScores := make([]float64, len(scores))
+	positive := make([]bool, len(scores))
+	for i := range scores {
+		if class < 0 || class >= len(scores[i]) {
+			return nil, nil, fmt.Errorf("Class %d is outside the range of classes", class)
+		}
+		classScores[i] = scores[i][class]
+		positive[i] = actual[i] == class
+	}
+
+	return classScores, positive, nil
+}
+
+// sweepPoint is the number of true and false positives when every score of at least threshold is counted as positive
+type sweepPoint struct {
+	threshold float64
+	tp        int
+	fp        int
+}
+
+// thresholdSweep lowers the threshold through every distinct score and counts the true and false positives at each one
+// The first point is above every score so nothing is positive and the last point counts everything as positive
+func thresholdSweep(scores []float64, positive []bool) ([]sweepPoint, error) {
+	if len(scores) != len(positive) {
+		return nil, errors.New("Number of scores doesn't match the number of labels")
+	}
+	if len(scores) == 0 {
+		return nil, errors.New("Curves need at least one sample")
+	}
+
+	order := sortedIndices(scores)
+	points := []sweepPoint{{threshold: scores[order[0]] + 1}}
+	tp, fp := 0, 0
+	for i, index := range order {
+		if positive[index] {
+			tp++
+		} else {
+			fp++
+		}
+
+		// Samples with the same score can't be split by a threshold so only add a point after the last of them
+		if i == len(order)-1 || scores[order[i+1]] != scores[index] {
+			points = append(points, sweepPoint{threshold: scores[index], tp: tp, fp: fp})
+		}
+	}
+
+	return points, nil
+}
+
+// trapezoid returns the area under a curve using the trapezoidal rule
+func trapezoid(x, y []float64) float64 {
+	area := 0.0
+	for i := 1; i < len(x); i++ {
+		area += (x[i] - x[i-1]) * (y[i] + y[i-1]) / 2
+	}
+	return area
+}
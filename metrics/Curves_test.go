@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+// checkSlice fails the test if the values aren't the same as want up to rounding
+func checkSlice(t *testing.T, name string, got []float64, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+		return
+	}
+	for i := range got {
+		if !closeTo(got[i], want[i]) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+	}
+}
+
+// TestROCCurve checks the points and area of the ROC curve on hand worked examples
+// The area matches the fraction of positive and negative pairs where the positive has the higher score
+func TestROCCurve(t *testing.T) {
+	tests := []struct {
+		name       string
+		scores     []float64
+		positive   []bool
+		x, y       []float64
+		thresholds []float64
+		auc        float64
+	}{
+		{
+			name:       "interleaved",
+			scores:     []float64{0.9, 0.8, 0.7, 0.6},
+			positive:   []bool{true, false, true, false},
+			x:          []float64{0, 0, 0.5, 0.5, 1},
+			y:          []float64{0, 0.5, 0.5, 1, 1},
+			thresholds: []float64{1.9, 0.9, 0.8, 0.7, 0.6},
+			auc:        0.75,
+		},
+		{
+			name:       "perfect",
+			scores:     []float64{0.2, 0.9, 0.1},
+			positive:   []bool{false, true, false},
+			x:          []float64{0, 0, 0.5, 1},
+			y:          []float64{0, 1, 1, 1},
+			thresholds: []float64{1.9, 0.9, 0.2, 0.1},
+			auc:        1,
+		},
+		{
+			name:       "tied scores",
+			scores:     []float64{0.5, 0.5},
+			positive:   []bool{true, false},
+			x:          []float64{0, 1},
+			y:          []float64{0, 1},
+			thresholds: []float64{1.5, 0.5},
+			auc:        0.5,
+		},
+	}
+
+	for _, test := range tests {
+		curve, err := ROCCurve(test.scores, test.positive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSlice(t, test.name+" X", curve.X, test.x)
+		checkSlice(t, test.name+" Y", curve.Y, test.y)
+		checkSlice(t, test.name+" Thresholds", curve.Thresholds, test.thresholds)
+		if !closeTo(curve.AUC, test.auc) {
+			t.Errorf("%s AUC = %f, want %f", test.name, curve.AUC, test.auc)
+		}
+	}
+}
+
+// TestPRCurve checks the points and area of the precision recall curve on a hand worked example
+func TestPRCurve(t *testing.T) {
+	curve, err := PRCurve([]float64{0.9, 0.8, 0.7, 0.6}, []bool{true, false, true, false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSlice(t, "X", curve.X, []float64{0, 0.5, 0.5, 1, 1})
+	checkSlice(t, "Y", curve.Y, []float64{1, 1, 0.5, 2.0 / 3, 0.5})
+	if want := 0.5 + 0.5*(0.5+2.0/3)/2; !closeTo(curve.AUC, want) {
+		t.Errorf("AUC = %f, want %f", curve.AUC, want)
+	}
+}
+
+// TestCurvesUndefined checks that curves without the samples they need have an AUC of NaN instead of 0
+func TestCurvesUndefined(t *testing.T) {
+	tests := []struct {
+		name     string
+		curve    func([]float64, []bool) (Curve, error)
+		positive []bool
+	}{
+		{"ROC without positives", ROCCurve, []bool{false, false}},
+		{"ROC without negatives", ROCCurve, []bool{true, true}},
+		{"PR without positives", PRCurve, []bool{false, false}},
+	}
+	for _, test := range tests {
+		curve, err := test.curve([]float64{0.3, 0.6}, test.positive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !math.IsNaN(curve.AUC) {
+			t.Errorf("%s: AUC = %f, want NaN", test.name, curve.AUC)
+		}
+	}
+}
+
+// TestCurveErrors checks that bad input is rejected
+func TestCurveErrors(t *testing.T) {
+	if _, err := ROCCurve([]float64{0.1}, []bool{true, false}); err == nil {
+		t.Error("Length mismatch didn't return an error")
+	}
+	if _, err := PRCurve(nil, nil); err == nil {
+		t.Error("No samples didn't return an error")
+	}
+	if _, err := OneVsRestROCCurve([][]float64{{0.1, 0.9}}, []int{1}, 2); err == nil {
+		t.Error("Class out of range didn't return an error")
+	}
+}
+
+// TestOneVsRestROCCurve checks that one vs rest curves use the scores of the class and treat the other classes as negative
+func TestOneVsRestROCCurve(t *testing.T) {
+	scores := [][]float64{{0.9, 0.1}, {0.8, 0.2}, {0.7, 0.3}, {0.6, 0.4}}
+	curve, err := OneVsRestROCCurve(scores, []int{0, 1, 0, 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closeTo(curve.AUC, 0.75) {
+		t.Errorf("AUC = %f, want 0.75", curve.AUC)
+	}
+}
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// This file holds the classification report that puts every metric together and formats it as text or json
+
+// ClassReport holds the metrics of a single class
+type ClassReport struct {
+	Class     int     `json:"class"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	Support   int     `json:"support"`
+
+	// ROC and PR are the one vs rest curves of the class
+	ROC Curve `json:"roc"`
+	PR  Curve `json:"pr"`
+}
+
+// AverageReport holds the precision, recall and F1 averaged over every class
+type AverageReport struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// Report holds every metric for a set of predictions
+type Report struct {
+	Total    int     `json:"total"`
+	Accuracy float64 `json:"accuracy"`
+
+	// TopK is the number of classes checked for TopKAccuracy
+	TopK         int     `json:"topK"`
+	TopKAccuracy float64 `json:"topKAccuracy"`
+
+	Classes  []ClassReport `json:"classes"`
+	Macro    AverageReport `json:"macro"`
+	Weighted AverageReport `json:"weighted"`
+	Micro    AverageReport `json:"micro"`
+
+	Confusion *ConfusionMatrix `json:"confusion"`
+}
+
+// CreateReport finds every metric from the scores the network gave each class for every sample and the actual classes
+// topK is the number of classes that are checked for the top k accuracy
+func CreateReport(scores [][]float64, actual []int, topK int) (*Report, error) {
+	if len(scores) == 0 {
+		return nil, errors.New("A report needs at least one sample")
+	}
+
+	classCount := len(scores[0])
+	for i := range scores {
+		if len(scores[i]) != classCount {
+			return nil, fmt.Errorf("Number of scores of sample %d doesn't match the rest of the samples", i)
+		}
+	}
+
+	confusion, err := CreateConfusionMatrix(Predictions(scores), actual, classCount)
+	if err != nil {
+		return nil, err
+	}
+
+	topKAccuracy, err := TopKAccuracy(scores, actual, topK)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Total:        confusion.Total(),
+		Accuracy:     confusion.Accuracy(),
+		TopK:         topK,
+		TopKAccuracy: topKAccuracy,
+		Macro: AverageReport{
+			Precision: confusion.MacroPrecision(),
+			Recall:    confusion.MacroRecall(),
+			F1:        confusion.MacroF1(),
+		},
+		Weighted: AverageReport{
+			Precision: confusion.WeightedPrecision(),
+			Recall:    confusion.WeightedRecall(),
+			F1:        confusion.WeightedF1(),
+		},
+		Micro: AverageReport{
+			Precision: confusion.MicroPrecision(),
+			Recall:    confusion.MicroRecall(),
+			F1:        confusion.MicroF1(),
+		},
+		Confusion: confusion,
+	}
+
+	for class := 0; class < classCount; class++ {
+		roc, err := OneVsRestROCCurve(scores, actual, class)
+		if err != nil {
+			return nil, err
+		}
+		pr, err := OneVsRestPRCurve(scores, actual, class)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Classes = append(report.Classes, ClassReport{
+			Class:     class,
+			Precision: confusion.Precision(class),
+			Recall:    confusion.Recall(class),
+			F1:        confusion.F1(class),
+			Support:   confusion.Support(class),
+			ROC:       roc,
+			PR:        pr,
+		})
+	}
+
+	return report, nil
+}
+
+// JSON returns the report as json, including the points of every curve
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Text returns the report as a human readable table, the curves are summarized by their area
+// which is NaN for classes the curve isn't defined for
+func (r *Report) Text() string {
+	var str strings.Builder
+
+	str.WriteString(fmt.Sprintf("%8s %10s %10s %10s %10s %10s %10s\n", "class", "precision", "recall", "f1", "support", "roc auc", "pr auc"))
+	for _, c := range r.Classes {
+		str.WriteString(fmt.Sprintf("%8d %10.4f %10.4f %10.4f %10d %10.4f %10.4f\n", c.Class, c.Precision, c.Recall, c.F1, c.Support, c.ROC.AUC, c.PR.AUC))
+	}
+	str.WriteByte('\n')
+	str.WriteString(fmt.Sprintf("%8s %10.4f %10.4f %10.4f %10d\n", "macro", r.Macro.Precision, r.Macro.Recall, r.Macro.F1, r.Total))
+	str.WriteString(fmt.Sprintf("%8s %10.4f %10.4f %10.4f %10d\n", "weighted", r.Weighted.Precision, r.Weighted.Recall, r.Weighted.F1, r.Total))
+	str.WriteString(fmt.Sprintf("%8s %10.4f %10.4f %10.4f %10d\n", "micro", r.Micro.Precision, r.Micro.Recall, r.Micro.F1, r.Total))
+	str.WriteByte('\n')
+	str.WriteString(fmt.Sprintf("Accuracy: %f, Top %d Accuracy: %f\n", r.Accuracy, r.TopK, r.TopKAccuracy))
+	str.WriteString("\nConfusion Matrix (rows are actual, columns are predicted):\n")
+	str.WriteString(r.Confusion.Text())
+
+	return str.String()
+}
+
+// Text returns the confusion matrix as a human readable table
+func (c *ConfusionMatrix) Text() string {
+	var str strings.Builder
+
+	str.WriteString(fmt.Sprintf("%6s", ""))
+	for j := range c.Counts {
+		str.WriteString(fmt.Sprintf(" %6d", j))
+	}
+	str.WriteByte('\n')
+
+	for i := range c.Counts {
+		str.WriteString(fmt.Sprintf("%6d", i))
+		for j := range c.Counts[i] {
+			str.WriteString(fmt.Sprintf(" %6d", c.Counts[i][j]))
+		}
+		str.WriteByte('\n')
+	}
+
+	return str.String()
+}
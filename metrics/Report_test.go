@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// createTestReport creates a report for three samples where class 2 never appears
+func createTestReport(t *testing.T) *Report {
+	scores := [][]float64{{0.9, 0.1, 0}, {0.2, 0.8, 0}, {0.6, 0.4, 0}}
+	report, err := CreateReport(scores, []int{0, 1, 1}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+// TestCreateReport checks the values in a report against the functions they come from
+func TestCreateReport(t *testing.T) {
+	report := createTestReport(t)
+
+	if report.Total != 3 || !closeTo(report.Accuracy, 2.0/3) || !closeTo(report.TopKAccuracy, 1) {
+		t.Errorf("Total %d, Accuracy %f, TopKAccuracy %f, want 3, 0.666667, 1", report.Total, report.Accuracy, report.TopKAccuracy)
+	}
+
+	classes := []struct {
+		precision, recall float64
+		support           int
+		rocAUC            float64
+	}{
+		{0.5, 1, 1, 1},
+		{1, 0.5, 2, 1},
+		{0, 0, 0, math.NaN()},
+	}
+	for i, want := range classes {
+		c := report.Classes[i]
+		if !closeTo(c.Precision, want.precision) || !closeTo(c.Recall, want.recall) || c.Support != want.support {
+			t.Errorf("Class %d: precision %f recall %f support %d, want %f %f %d", i, c.Precision, c.Recall, c.Support, want.precision, want.recall, want.support)
+		}
+		if math.IsNaN(want.rocAUC) != math.IsNaN(c.ROC.AUC) || (!math.IsNaN(want.rocAUC) && !closeTo(c.ROC.AUC, want.rocAUC)) {
+			t.Errorf("Class %d: ROC AUC %f, want %f", i, c.ROC.AUC, want.rocAUC)
+		}
+	}
+
+	if !closeTo(report.Macro.Precision, 0.5) || !closeTo(report.Weighted.Precision, 2.5/3) || !closeTo(report.Micro.Precision, 2.0/3) {
+		t.Errorf("Macro %f, Weighted %f, Micro %f precision, want 0.5, 0.833333, 0.666667", report.Macro.Precision, report.Weighted.Precision, report.Micro.Precision)
+	}
+}
+
+// TestReportJSON checks that the report can be written as json with undefined areas written as null
+func TestReportJSON(t *testing.T) {
+	data, err := createTestReport(t).JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Accuracy float64 `json:"accuracy"`
+		Classes  []struct {
+			ROC struct {
+				X   []float64 `json:"x"`
+				AUC *float64  `json:"auc"`
+			} `json:"roc"`
+		} `json:"classes"`
+		Confusion struct {
+			Counts [][]int `json:"counts"`
+		} `json:"confusion"`
+	}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !closeTo(decoded.Accuracy, 2.0/3) {
+		t.Errorf("accuracy = %f, want 0.666667", decoded.Accuracy)
+	}
+	if decoded.Classes[0].ROC.AUC == nil || *decoded.Classes[0].ROC.AUC != 1 || len(decoded.Classes[0].ROC.X) == 0 {
+		t.Errorf("Class 0 roc = %+v, want an auc of 1 and its points", decoded.Classes[0].ROC)
+	}
+	if decoded.Classes[2].ROC.AUC != nil {
+		t.Errorf("Class 2 roc auc = %f, want null", *decoded.Classes[2].ROC.AUC)
+	}
+	if decoded.Confusion.Counts[1][0] != 1 || decoded.Confusion.Counts[1][1] != 1 {
+		t.Errorf("confusion counts = %v", decoded.Confusion.Counts)
+	}
+}
+
+// TestReportText checks the tables in the text report
+func TestReportText(t *testing.T) {
+	text := createTestReport(t).Text()
+
+	lines := []string{
+		"   class  precision     recall         f1    support    roc auc     pr auc",
+		"       0     0.5000     1.0000     0.6667          1     1.0000     1.0000",
+		"       2     0.0000     0.0000     0.0000          0        NaN        NaN",
+		"   macro     0.5000     0.5000     0.4444          3",
+		"weighted     0.8333     0.6667     0.6667          3",
+		"Accuracy: 0.666667, Top 2 Accuracy: 1.000000",
+		"            0      1      2\n     0      1      0      0\n     1      1      1      0\n     2      0      0      0\n",
+	}
+	for _, line := range lines {
+		if !strings.Contains(text, line) {
+			t.Errorf("Report text doesn't contain %q:\n%s", line, text)
+		}
+	}
+}
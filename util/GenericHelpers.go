@@ -12,6 +12,9 @@ import (
 var r *rand.Rand
 
 // GetRand returns a global pseudo random number generator
+// It is seeded from the clock unless SetSeed or SetSource was called first
+// Everything in the library that is random and isn't given its own generator uses it,
+// so seeding it makes weight initialization, shuffling, data set splits and dropout reproducible
 func GetRand() *rand.Rand {
 	if r == nil {
 		r = rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
@@ -19,6 +22,16 @@ func GetRand() *rand.Rand {
 	return r
 }
 
+// SetSeed replaces the global pseudo random number generator with one seeded with the given seed
+func SetSeed(seed int64) {
+	r = rand.New(rand.NewSource(seed))
+}
+
+// SetSource replaces the global pseudo random number generator with one that uses the given source
+func SetSource(source rand.Source) {
+	r = rand.New(source)
+}
+
 // PrintMatrix prints a dense to the console in a human readable format
 func PrintMatrix(data mat.Matrix) {
 	f := mat.Formatted(data, mat.Prefix("    "), mat.Squeeze())